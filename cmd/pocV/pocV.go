@@ -2,6 +2,9 @@ package main
 
 import (
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/WAY29/errors"
@@ -9,11 +12,14 @@ import (
 
 	"github.com/WAY29/pocV/internal/common/check"
 	. "github.com/WAY29/pocV/internal/common/load"
+	"github.com/WAY29/pocV/internal/common/metrics"
 	"github.com/WAY29/pocV/internal/common/output"
+	"github.com/WAY29/pocV/internal/common/progress"
 	"github.com/WAY29/pocV/utils"
 
 	common_structs "github.com/WAY29/pocV/pkg/common/structs"
 	nuclei_parse "github.com/WAY29/pocV/pkg/nuclei/parse"
+	nuclei_structs "github.com/WAY29/pocV/pkg/nuclei/structs"
 	xray_requests "github.com/WAY29/pocV/pkg/xray/requests"
 	xray_structs "github.com/WAY29/pocV/pkg/xray/structs"
 )
@@ -30,25 +36,46 @@ func cmdRun(cmd *cli.Cmd) {
 
 	// 定义选项
 	var (
-		target      = cmd.StringsOpt("t target", make([]string, 0), "Target url(s)")
-		targetFiles = cmd.StringsOpt("T targetfile", make([]string, 0), "Target url file(s)")
-		poc         = cmd.StringsOpt("p poc", make([]string, 0), "Poc file(s)")
-		pocPath     = cmd.StringsOpt("P pocpath", make([]string, 0), "Load poc from Path, support Glob grammer")
-		apiKey      = cmd.StringOpt("k key", "", "ceye.io api key")
-		domain      = cmd.StringOpt("d domain", "", "ceye.io subdomain")
-		tags        = cmd.StringsOpt("tag", make([]string, 0), "filter poc by tag")
-		file        = cmd.StringOpt("file", "", "Result file to write")
-		json        = cmd.BoolOpt("json", false, "Whether output is in JSON format or not, more information will be output")
-		success     = cmd.BoolOpt("success", false, "Only output success result")
-		proxy       = cmd.StringOpt("proxy", "", "Http proxy")
-		threads     = cmd.IntOpt("threads", 10, "Thread number")
-		timeout     = cmd.IntOpt("timeout", 20, "Request timeout")
-		rate        = cmd.IntOpt("rate", 100, "Request rate(per second)")
-		debug       = cmd.BoolOpt("debug", false, "Debug this program")
-		verbose     = cmd.BoolOpt("v verbose", false, "Print verbose messages")
+		target        = cmd.StringsOpt("t target", make([]string, 0), "Target url(s)")
+		targetFiles   = cmd.StringsOpt("T targetfile", make([]string, 0), "Target url file(s)")
+		poc           = cmd.StringsOpt("p poc", make([]string, 0), "Poc file(s)")
+		pocPath       = cmd.StringsOpt("P pocpath", make([]string, 0), "Load poc from Path, support Glob grammer")
+		apiKey        = cmd.StringOpt("k key", "", "ceye.io api key")
+		domain        = cmd.StringOpt("d domain", "", "ceye.io subdomain")
+		tags          = cmd.StringsOpt("tag", make([]string, 0), "filter poc by tag")
+		file          = cmd.StringOpt("file", "", "Result file to write")
+		json          = cmd.BoolOpt("json", false, "Whether output is in JSON format or not, more information will be output")
+		success       = cmd.BoolOpt("success", false, "Only output success result")
+		headers       = cmd.StringsOpt("H header", make([]string, 0), "Custom header(s) for every request, e.g. \"Name: value\"")
+		headerFiles   = cmd.StringsOpt("header-file", make([]string, 0), "File(s) of custom headers, one \"Name: value\" per line")
+		cookie        = cmd.StringOpt("cookie", "", "Cookie for every request")
+		auth          = cmd.StringOpt("auth", "", "Authentication for every request, basic:<username>:<password> or bearer:<token>")
+		disableCookie = cmd.BoolOpt("disable-cookie", false, "Disable cookie handling, by default each poc execution has its own cookie session")
+		http2         = cmd.BoolOpt("http2", false, "Negotiate HTTP/2 for https requests")
+		maxBodySize   = cmd.IntOpt("max-body-size", 10*1024*1024, "Max response body size(bytes) to read, 0 means unlimited")
+		decodeCharset = cmd.BoolOpt("decode-charset", false, "Transcode GBK/Big5/Shift-JIS response body to UTF-8 as response.utf8_body")
+		proxy         = cmd.StringOpt("proxy", "", "Proxy, support http(s)://host:port and socks5://[user:pass@]host:port")
+		proxyFiles    = cmd.StringsOpt("proxy-file", make([]string, 0), "File(s) of proxies, one per line, used in rotation")
+		resolves      = cmd.StringsOpt("resolve", make([]string, 0), "Resolve host to ip, e.g. example.com:127.0.0.1")
+		resolvers     = cmd.StringsOpt("resolvers", make([]string, 0), "Custom DNS server(s), separated by comma")
+		clientCert    = cmd.StringOpt("client-cert", "", "Client certificate file(PEM) for mutual TLS")
+		clientKey     = cmd.StringOpt("client-key", "", "Client private key file(PEM) for mutual TLS")
+		clientCA      = cmd.StringOpt("client-ca", "", "CA certificate file(PEM) of client certificate, only used by nuclei")
+		tlsMin        = cmd.StringOpt("tls-min", "", "Minimum TLS version, 1.0/1.1/1.2/1.3")
+		tlsMax        = cmd.StringOpt("tls-max", "", "Maximum TLS version, 1.0/1.1/1.2/1.3")
+		ciphers       = cmd.StringsOpt("ciphers", make([]string, 0), "TLS cipher suite(s), separated by comma, e.g. TLS_RSA_WITH_AES_128_CBC_SHA")
+		sni           = cmd.StringOpt("sni", "", "Override TLS server name(SNI), only for a single target")
+		threads       = cmd.IntOpt("threads", 10, "Thread number")
+		timeout       = cmd.IntOpt("timeout", 20, "Request timeout")
+		rate          = cmd.IntOpt("rate", 100, "Request rate(per second)")
+		debug         = cmd.BoolOpt("debug", false, "Debug this program")
+		statsJson     = cmd.BoolOpt("stats-json", false, "Periodically print scan statistics as JSON lines instead of the status line")
+		statsInterval = cmd.IntOpt("stats-interval", 5, "Interval(second) between JSON statistics lines")
+		metricsListen = cmd.StringOpt("metrics-listen", "", "Expose prometheus metrics on this address, e.g. 127.0.0.1:9090")
+		verbose       = cmd.BoolOpt("v verbose", false, "Print verbose messages")
 	)
 	// 定义用法
-	cmd.Spec = "(-t=<target> | -T=<targetFile>)... (-p=<poc> | -P=<pocpath>)... [--tag=<poc.tag>]... [--file=<file> [--json]] [--success] [--proxy=<proxy>] [--threads=<threads>] [--timeout=<timeout>] [-k=<ceye.api.key> | --key=<ceye.api.key>]  [-d=<ceye.subdomain> | --domain=<ceye.subdomain>] [--debug] [-v | --verbose]"
+	cmd.Spec = "(-t=<target> | -T=<targetFile>)... (-p=<poc> | -P=<pocpath>)... [--tag=<poc.tag>]... [--file=<file> [--json]] [--success] [-H=<header>]... [--header-file=<header.file>]... [--cookie=<cookie>] [--auth=<auth>] [--disable-cookie] [--http2] [--max-body-size=<max.body.size>] [--decode-charset] [--proxy=<proxy>] [--proxy-file=<proxy.file>]... [--resolve=<host:ip>]... [--resolvers=<resolvers>]... [--client-cert=<client.cert> [--client-key=<client.key>] [--client-ca=<client.ca>]] [--tls-min=<tls.min>] [--tls-max=<tls.max>] [--ciphers=<ciphers>]... [--sni=<sni>] [--threads=<threads>] [--timeout=<timeout>] [--rate=<rate>] [--stats-json [--stats-interval=<stats.interval>]] [--metrics-listen=<metrics.listen>] [-k=<ceye.api.key> | --key=<ceye.api.key>]  [-d=<ceye.subdomain> | --domain=<ceye.subdomain>] [--debug] [-v | --verbose]"
 
 	cmd.Action = func() {
 		// 设置变量
@@ -66,15 +93,65 @@ func cmdRun(cmd *cli.Cmd) {
 			utils.WarningF("No Ceye api, use dnslog.cn")
 		}
 
-		// 初始化http客户端
-		xray_requests.InitHttpClient(*threads, *proxy, timeoutSecond)
-
-		// 初始化nuclei options
-		nuclei_parse.InitExecuterOptions(*rate, *timeout)
+		// 初始化dns解析
+		nameservers := make([]string, 0, len(*resolvers))
+		for _, r := range *resolvers {
+			nameservers = append(nameservers, strings.Split(r, ",")...)
+		}
+		if err := xray_requests.InitResolver(*resolves, nameservers); err != nil {
+			utils.CliError(err.Error(), 2)
+		}
 
 		// 加载目标
 		targets := LoadTargets(target, targetFiles)
 
+		// 初始化tls配置，SNI作用于所有连接，只允许单个目标使用
+		if *sni != "" && len(targets) > 1 {
+			utils.CliError("--sni can only be used with a single target", 2)
+		}
+		cipherSuites := make([]string, 0, len(*ciphers))
+		for _, c := range *ciphers {
+			cipherSuites = append(cipherSuites, strings.Split(c, ",")...)
+		}
+		if err := xray_requests.InitTLSConfig(*clientCert, *clientKey, *tlsMin, *tlsMax, cipherSuites, *sni); err != nil {
+			utils.CliError(err.Error(), 2)
+		}
+
+		// 初始化http客户端
+		proxies := LoadProxies(*proxy, proxyFiles)
+		if err := xray_requests.InitHttpClient(*threads, proxies, timeoutSecond, *disableCookie, *http2, int64(*maxBodySize), *decodeCharset); err != nil {
+			utils.CliError(err.Error(), 2)
+		}
+		if xray_requests.Proxies.Len() > 0 {
+			utils.WarningF("Proxy can't forward udp, udp pocs will fail instead of connecting directly")
+		}
+
+		// 初始化进度统计，debug模式下不输出状态行
+		scanProgress := progress.InitProgress(*statsJson, !*debug, time.Duration(*statsInterval)*time.Second)
+
+		// 加载自定义请求头
+		customHeaders := LoadHeaders(headers, headerFiles, *cookie, *auth)
+
+		// 初始化metrics服务
+		if err := metrics.InitMetrics(*metricsListen); err != nil {
+			utils.CliError(err.Error(), 2)
+		}
+
+		// 初始化nuclei options
+		nuclei_parse.InitExecuterOptions(nuclei_structs.Options{
+			Rate:       *rate,
+			Timeout:    *timeout,
+			Progress:   &metrics.NucleiProgress{Progress: scanProgress},
+			Headers:    customHeaders,
+			Proxies:    xray_requests.Proxies.Strings(),
+			Hosts:      xray_requests.Resolver.Hosts(),
+			Resolvers:  xray_requests.Resolver.Nameservers(),
+			ClientCert: *clientCert,
+			ClientKey:  *clientKey,
+			ClientCA:   *clientCA,
+			TLSConfig:  xray_requests.TLSConfig,
+		})
+
 		// 加载poc
 		xrayPocs, nucleiPocs := LoadPocs(poc, pocPath)
 		// 过滤poc
@@ -86,7 +163,7 @@ func cmdRun(cmd *cli.Cmd) {
 		for _, poc := range xrayPocs {
 			ruleLens := len(poc.Rules)
 			// 额外需要缓存connectionID
-			if poc.Transport == "tcp" || poc.Transport == "udp" {
+			if poc.Transport == "tcp" || poc.Transport == "udp" || poc.Transport == "tls" {
 				ruleLens += 1
 			}
 			xrayTotalReqeusts += totalTargets * ruleLens
@@ -100,7 +177,10 @@ func cmdRun(cmd *cli.Cmd) {
 		outputChannel, outputWg := output.InitOutput(*file, *json, *success)
 
 		// 初始化check
-		check.InitCheck(*threads, *rate, *verbose)
+		check.InitCheck(*threads, *rate, *verbose, customHeaders)
+
+		// 处理中断信号
+		handleSignal()
 
 		// check开始
 		check.Start(targets, xrayPocs, nucleiPocs, outputChannel)
@@ -109,11 +189,31 @@ func cmdRun(cmd *cli.Cmd) {
 		// check结束
 		close(outputChannel)
 		check.End()
+		xray_requests.CloseConnections()
 		outputWg.Wait()
+		scanProgress.Stop()
 
+		// 输出扫描统计
+		check.Summary.Print()
 	}
 }
 
+// 第一次中断停止分发任务并等待运行中的任务结束，第二次中断强制退出
+func handleSignal() {
+	signalChannel := make(chan os.Signal, 2)
+	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-signalChannel
+		utils.Message("Interrupted, waiting for running tasks to finish, press Ctrl-C again to force exit")
+		check.Stop()
+
+		<-signalChannel
+		utils.Exit("Force exit")
+		os.Exit(130)
+	}()
+}
+
 func init() {
 	errors.SetCurrentAbsPath()
 	errors.SetSkipFrameNum(4)
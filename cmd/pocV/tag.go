@@ -4,6 +4,7 @@ import (
 	. "github.com/WAY29/pocV/internal/common/load"
 	"github.com/WAY29/pocV/internal/common/tag"
 	nuclei_parse "github.com/WAY29/pocV/pkg/nuclei/parse"
+	nuclei_structs "github.com/WAY29/pocV/pkg/nuclei/structs"
 	"github.com/WAY29/pocV/utils"
 
 	cli "github.com/jawher/mow.cli"
@@ -26,7 +27,10 @@ func cmdTag(cmd *cli.Cmd) {
 		utils.InitLog(*debug, *verbose)
 
 		// 初始化nuclei options
-		nuclei_parse.InitExecuterOptions(100, 10)
+		nuclei_parse.InitExecuterOptions(nuclei_structs.Options{
+			Rate:    100,
+			Timeout: 10,
+		})
 
 		xrayPocMap, nucleiPocMap := LoadPocs(poc, pocPath)
 
@@ -4,20 +4,28 @@ go 1.16
 
 require (
 	github.com/WAY29/errors v1.6.0
+	github.com/andybalholm/brotli v1.0.4
 	github.com/blang/semver v3.5.1+incompatible
 	github.com/bluele/gcache v0.0.2
 	github.com/dlclark/regexp2 v1.4.0
 	github.com/google/cel-go v0.9.0
 	github.com/jawher/mow.cli v1.2.0
 	github.com/logrusorgru/aurora v2.0.3+incompatible
+	github.com/mattn/go-isatty v0.0.14
 	github.com/panjf2000/ants v1.3.0
+	github.com/projectdiscovery/fastdialer v0.0.15-0.20220127193345-f06b0fd54d47
 	github.com/projectdiscovery/nuclei/v2 v2.6.0
+	github.com/projectdiscovery/retryablehttp-go v1.0.2
+	github.com/prometheus/client_golang v1.11.0
+	github.com/prometheus/client_model v0.2.0
 	github.com/remeh/sizedwaitgroup v1.0.0
 	github.com/rhysd/go-github-selfupdate v1.2.3
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spaolacci/murmur3 v1.1.0
 	github.com/x-cray/logrus-prefixed-formatter v0.5.2
 	go.uber.org/ratelimit v0.2.0
+	golang.org/x/net v0.0.0-20211216030914-fe4d6282115f
+	golang.org/x/text v0.3.7
 	google.golang.org/genproto v0.0.0-20220217155828-d576998c0009
 	google.golang.org/protobuf v1.27.1
 	gopkg.in/yaml.v2 v2.4.0
@@ -3,11 +3,15 @@ package check
 import (
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/WAY29/pocV/internal/common/errors"
+	"github.com/WAY29/pocV/internal/common/metrics"
+	"github.com/WAY29/pocV/internal/common/progress"
 	common_structs "github.com/WAY29/pocV/pkg/common/structs"
 	nuclei_structs "github.com/WAY29/pocV/pkg/nuclei/structs"
 	xray_structs "github.com/WAY29/pocV/pkg/xray/structs"
@@ -22,11 +26,15 @@ var (
 	Ticker  *time.Ticker
 	Pool    *ants.PoolWithFunc
 	Verbose bool
+	Headers http.Header
 
 	WaitGroup sync.WaitGroup
 
 	OutputChannel chan common_structs.Result
 
+	Summary = &ScanSummary{}
+	stopped int32
+
 	ResultPool = sync.Pool{
 		New: func() interface{} {
 			return new(common_structs.PocResult)
@@ -35,7 +43,7 @@ var (
 )
 
 // 初始化协程池
-func InitCheck(threads, rate int, verbose bool) {
+func InitCheck(threads, rate int, verbose bool, headers http.Header) {
 	var err error
 
 	rateLimit := time.Second / time.Duration(rate)
@@ -46,6 +54,8 @@ func InitCheck(threads, rate int, verbose bool) {
 	}
 
 	Verbose = verbose
+	Headers = headers
+	Summary.StartTime = time.Now()
 }
 
 // 将任务放入协程池
@@ -53,8 +63,19 @@ func Start(targets []string, xrayPocMap map[string]xray_structs.Poc, nucleiPocMa
 	// 设置outputChannel
 	OutputChannel = outputChannel
 
+	// 设置任务总数，开始输出进度
+	progress.Progress.Start(int64(len(targets) * (len(xrayPocMap) + len(nucleiPocMap))))
+
 	for _, target := range targets {
+		if IsStopped() {
+			return
+		}
+		Summary.AddTarget()
+
 		for _, poc := range xrayPocMap {
+			if IsStopped() {
+				return
+			}
 			WaitGroup.Add(1)
 			Pool.Invoke(&xray_structs.Task{
 				Target: target,
@@ -62,6 +83,9 @@ func Start(targets []string, xrayPocMap map[string]xray_structs.Poc, nucleiPocMa
 			})
 		}
 		for _, poc := range nucleiPocMap {
+			if IsStopped() {
+				return
+			}
 			WaitGroup.Add(1)
 			Pool.Invoke(&nuclei_structs.Task{
 				Target: target,
@@ -81,6 +105,15 @@ func End() {
 	Pool.Release()
 }
 
+// 停止分发新任务，已在运行的任务会继续执行完毕
+func Stop() {
+	atomic.StoreInt32(&stopped, 1)
+}
+
+func IsStopped() bool {
+	return atomic.LoadInt32(&stopped) == 1
+}
+
 // 核心代码，poc检测
 func check(taskInterface interface{}) {
 	var (
@@ -91,7 +124,17 @@ func check(taskInterface interface{}) {
 	)
 
 	defer WaitGroup.Done()
+	defer progress.Progress.IncrementTasksDone()
+
+	// 已停止则跳过尚未开始的任务，无需等待速率限制
+	if IsStopped() {
+		return
+	}
 	<-Ticker.C
+	if IsStopped() {
+		return
+	}
+	Summary.AddPoc()
 
 	switch taskInterface.(type) {
 	case *xray_structs.Task:
@@ -104,24 +147,51 @@ func check(taskInterface interface{}) {
 		target, poc := task.Target, task.Poc
 
 		pocName = poc.Name
-		if poc.Transport != "tcp" && poc.Transport != "udp" {
+		if poc.Transport != "tcp" && poc.Transport != "udp" && poc.Transport != "tls" {
 			oRequest, _ = http.NewRequest("GET", target, nil)
+			// 设置自定义请求头，所有rule都会继承
+			if Headers != nil {
+				oRequest.Header = Headers.Clone()
+			}
 		}
 
-		isVul, err := executeXrayPoc(oRequest, target, &poc)
+		result, err := executeXrayPoc(oRequest, target, &poc)
 		if err != nil {
+			Summary.AddError()
+			metrics.ObserveError(err)
 			utils.ErrorP(err)
 			return
 		}
+		if result.IsVul {
+			Summary.AddVuln()
+			progress.Progress.IncrementMatched()
+			metrics.IncrementMatches("xray")
+		}
 
 		pocResult := ResultPool.Get().(*common_structs.PocResult)
 		pocResult.Str = fmt.Sprintf("%s (%s)", target, pocName)
-		pocResult.Success = isVul
+		pocResult.Success = result.IsVul
 		pocResult.URL = target
 		pocResult.PocName = poc.Name
-		pocResult.PocLink = poc.Detail.Links
-		pocResult.PocAuthor = poc.Detail.Author
-		pocResult.PocDescription = poc.Detail.Description
+		pocResult.PocLink = result.Detail.Links
+		pocResult.PocAuthor = result.Detail.Author
+		pocResult.PocDescription = result.Detail.Description
+
+		// 输出命中的payload
+		for _, payload := range result.Payloads {
+			payloadMap := make(map[string]string, len(payload))
+			payloadStrings := make([]string, 0, len(payload))
+			for _, item := range payload {
+				k, v := fmt.Sprintf("%v", item.Key), fmt.Sprintf("%v", item.Value)
+				payloadMap[k] = v
+				payloadStrings = append(payloadStrings, k+"="+v)
+			}
+			pocResult.Payloads = append(pocResult.Payloads, payloadMap)
+			pocResult.Str += " [" + strings.Join(payloadStrings, ", ") + "]"
+		}
+		// 输出提取的变量
+		pocResult.Extracted = result.Extracted
+		pocResult.Str += formatExtracted(result.Extracted)
 
 		OutputChannel <- pocResult
 
@@ -148,10 +218,19 @@ func check(taskInterface interface{}) {
 
 		results, isVul, err := executeNucleiPoc(target, &poc)
 		if err != nil {
+			Summary.AddError()
+			metrics.ObserveError(err)
 			utils.ErrorP(err)
 			return
 		}
 
+		// 一个poc可能返回多个结果，按poc计数
+		if isVul {
+			Summary.AddVuln()
+			progress.Progress.IncrementMatched()
+			metrics.IncrementMatches("nuclei")
+		}
+
 		for _, r := range results {
 			if r.ExtractorName != "" {
 				desc = r.TemplateID + ":" + r.ExtractorName
@@ -167,6 +246,10 @@ func check(taskInterface interface{}) {
 			pocResult.PocLink = EmptyLinks
 			pocResult.PocAuthor = author
 			pocResult.PocDescription = desc
+			if extracted := nucleiExtracted(r); extracted != nil {
+				pocResult.Extracted = extracted
+				pocResult.Str += formatExtracted(extracted)
+			}
 
 			OutputChannel <- pocResult
 		}
@@ -174,6 +257,26 @@ func check(taskInterface interface{}) {
 
 }
 
+// 按变量名排序输出提取的变量
+func formatExtracted(extracted map[string]string) string {
+	if len(extracted) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(extracted))
+	for k := range extracted {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	extractedStrings := make([]string, 0, len(keys))
+	for _, k := range keys {
+		extractedStrings = append(extractedStrings, k+"="+extracted[k])
+	}
+
+	return " {" + strings.Join(extractedStrings, ", ") + "}"
+}
+
 func PutPocResult(result *common_structs.PocResult) {
 	result.Str = ""
 	result.Success = false
@@ -182,6 +285,8 @@ func PutPocResult(result *common_structs.PocResult) {
 	result.PocLink = nil
 	result.PocDescription = ""
 	result.PocAuthor = ""
+	result.Payloads = nil
+	result.Extracted = nil
 
 	ResultPool.Put(result)
 }
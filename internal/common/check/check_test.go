@@ -0,0 +1,113 @@
+package check
+
+import (
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/WAY29/pocV/internal/common/metrics"
+	"github.com/WAY29/pocV/internal/common/progress"
+	common_structs "github.com/WAY29/pocV/pkg/common/structs"
+	xray_structs "github.com/WAY29/pocV/pkg/xray/structs"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"gopkg.in/yaml.v2"
+)
+
+func TestCheckStoppedSkipsRateLimit(t *testing.T) {
+	oldTicker := Ticker
+	Ticker = time.NewTicker(time.Hour)
+	defer func() {
+		Ticker.Stop()
+		Ticker = oldTicker
+	}()
+
+	atomic.StoreInt32(&stopped, 1)
+	defer atomic.StoreInt32(&stopped, 0)
+
+	// 停止后排队中的任务不等待速率限制直接跳过
+	done := make(chan struct{})
+	WaitGroup.Add(1)
+	go func() {
+		check(&xray_structs.Task{Target: "http://127.0.0.1"})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stopped task waited for rate limit tick")
+	}
+}
+
+// errors_total所有类型的总数
+func errorsTotal(t *testing.T) float64 {
+	ch := make(chan prometheus.Metric, 64)
+	metrics.ErrorsTotal.Collect(ch)
+	close(ch)
+
+	total := 0.0
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			t.Fatal(err)
+		}
+		total += metric.GetCounter().GetValue()
+	}
+	return total
+}
+
+func TestCheckErrorCounts(t *testing.T) {
+	oldTicker, oldProgress, oldSummary, oldOutput := Ticker, progress.Progress, Summary, OutputChannel
+	Ticker = time.NewTicker(time.Millisecond)
+	OutputChannel = make(chan common_structs.Result, 4)
+	defer func() {
+		Ticker.Stop()
+		Ticker, progress.Progress, Summary, OutputChannel = oldTicker, oldProgress, oldSummary, oldOutput
+	}()
+
+	// 关闭的端口，请求失败
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := "http://" + listener.Addr().String()
+	listener.Close()
+
+	tests := []struct {
+		name                 string
+		poc                  string
+		requests, errors     int64
+		summaryErrors, found int64
+		metricErrors         float64
+	}{
+		// 失败的请求只计入进度统计的错误数，poc未命中
+		{"request error", racePoc, 1, 1, 0, 0, 0},
+		// 执行失败的任务只计入Summary
+		{"task error", strings.Replace(racePoc, "expression: r0() && r1()", "expression: r0() &&", 1), 0, 0, 1, 0, 1},
+	}
+
+	for _, tt := range tests {
+		progress.Progress = &progress.ScanProgress{}
+		Summary = &ScanSummary{}
+		metricErrors := errorsTotal(t)
+
+		var poc xray_structs.Poc
+		if err := yaml.Unmarshal([]byte(tt.poc), &poc); err != nil {
+			t.Fatal(err)
+		}
+		WaitGroup.Add(1)
+		check(&xray_structs.Task{Target: target, Poc: poc})
+
+		stats := progress.Progress.Stats()
+		if stats.Requests != tt.requests || stats.Errors != tt.errors || Summary.Errors != tt.summaryErrors || Summary.Vulns != tt.found {
+			t.Errorf("%s: got requests %d errors %d summary errors %d vulns %d", tt.name, stats.Requests, stats.Errors, Summary.Errors, Summary.Vulns)
+		}
+		// errors_total只记录执行失败的任务，不包括输出的其他错误日志
+		if got := errorsTotal(t) - metricErrors; got != tt.metricErrors {
+			t.Errorf("%s: got errors_total increased by %v, want %v", tt.name, got, tt.metricErrors)
+		}
+	}
+}
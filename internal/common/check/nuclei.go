@@ -1,6 +1,8 @@
 package check
 
 import (
+	"strings"
+
 	"github.com/WAY29/pocV/internal/common/errors"
 	nuclei_structs "github.com/WAY29/pocV/pkg/nuclei/structs"
 	"github.com/WAY29/pocV/utils"
@@ -34,3 +36,16 @@ func executeNucleiPoc(target string, poc *nuclei_structs.Poc) (results []*output
 	}
 	return results, isVul, err
 }
+
+// extractor提取的结果，未命名的extractor使用extracted作为变量名
+func nucleiExtracted(r *output.ResultEvent) map[string]string {
+	if len(r.ExtractedResults) == 0 {
+		return nil
+	}
+
+	name := r.ExtractorName
+	if name == "" {
+		name = "extracted"
+	}
+	return map[string]string{name: strings.Join(r.ExtractedResults, ", ")}
+}
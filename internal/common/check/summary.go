@@ -0,0 +1,44 @@
+package check
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/WAY29/pocV/utils"
+)
+
+// 扫描统计
+type ScanSummary struct {
+	Targets   int64
+	Pocs      int64
+	Vulns     int64
+	Errors    int64
+	StartTime time.Time
+}
+
+func (s *ScanSummary) AddTarget() {
+	atomic.AddInt64(&s.Targets, 1)
+}
+
+func (s *ScanSummary) AddPoc() {
+	atomic.AddInt64(&s.Pocs, 1)
+}
+
+func (s *ScanSummary) AddVuln() {
+	atomic.AddInt64(&s.Vulns, 1)
+}
+
+func (s *ScanSummary) AddError() {
+	atomic.AddInt64(&s.Errors, 1)
+}
+
+// 输出扫描统计
+func (s *ScanSummary) Print() {
+	elapsed := time.Since(s.StartTime).Round(time.Millisecond)
+
+	utils.MessageF("Targets scanned: %d", atomic.LoadInt64(&s.Targets))
+	utils.MessageF("Pocs run: %d", atomic.LoadInt64(&s.Pocs))
+	utils.MessageF("Vulns found: %d", atomic.LoadInt64(&s.Vulns))
+	utils.MessageF("Errors: %d", atomic.LoadInt64(&s.Errors))
+	utils.MessageF("Elapsed time: %s", elapsed)
+}
@@ -2,22 +2,25 @@ package check
 
 import (
 	"fmt"
-	"io"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/WAY29/pocV/internal/common/errors"
+	"github.com/WAY29/pocV/internal/common/metrics"
+	"github.com/WAY29/pocV/internal/common/progress"
 	"github.com/WAY29/pocV/pkg/xray/cel"
 	"github.com/WAY29/pocV/pkg/xray/requests"
 	xray_structs "github.com/WAY29/pocV/pkg/xray/structs"
 	"github.com/WAY29/pocV/utils"
 	"github.com/google/cel-go/checker/decls"
+	"google.golang.org/protobuf/proto"
 	"gopkg.in/yaml.v2"
 )
 
@@ -27,17 +30,44 @@ var (
 			return make([]byte, 1024)
 		},
 	}
-	BodyPool = sync.Pool{
-		New: func() interface{} {
-			return make([]byte, 4096)
-		},
-	}
 )
 
+// tcp/udp rule未设置read_timeout时的读取超时
+const DefaultReadTimeout = 5
+
 type RequestFuncType func(ruleName string, rule xray_structs.Rule) error
 
-func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (isVul bool, err error) {
-	isVul = false
+// xray poc执行结果
+type xrayPocResult struct {
+	IsVul bool
+	// 渲染后的detail
+	Detail xray_structs.Detail
+	// 命中的payload
+	Payloads []yaml.MapSlice
+	// rule output中提取的变量
+	Extracted map[string]string
+}
+
+// 将output变量转换为字符串，map类型(如submatch结果)展开为key.name
+func addExtracted(extracted map[string]string, k string, value interface{}) {
+	switch value := value.(type) {
+	case *xray_structs.Reverse:
+		return
+	case map[string]string:
+		for name, v := range value {
+			extracted[k+"."+name] = v
+		}
+	case []byte:
+		extracted[k] = string(value)
+	default:
+		extracted[k] = fmt.Sprintf("%v", value)
+	}
+}
+
+// 执行xray poc，poc在多个目标间共享，执行过程中不能修改
+func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (result xrayPocResult, err error) {
+	result.Detail = poc.Detail.Clone()
+	detail := &result.Detail
 
 	var (
 		milliseconds int64
@@ -51,20 +81,31 @@ func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (i
 		variableMap   map[string]interface{} = make(map[string]interface{})
 
 		oReqUrlString string
+		// 请求和响应被缓存后可能被其他协程使用，不能回收
+		protoRequestCached  bool
+		protoResponseCached bool
+		// set中是否覆盖了request.url.query
+		urlQueryOverridden bool
 
 		requestFunc cel.RequestFuncType
+
+		// 每次poc执行使用独立的cookie会话
+		cookieJar = requests.NewCookieJar()
+		// 每次poc执行使用独立的connection_id连接
+		connSession = requests.NewConnectionSession(target, poc.Name)
 	)
+	defer connSession.Close()
 
 	// 异常处理
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.Wrapf(r.(error), "Run Xray Poc[%s] error", poc.Name)
-			isVul = false
+			result.IsVul = false
 		}
 	}()
 	// 回收
 	defer func() {
-		if protoRequest != nil {
+		if protoRequest != nil && !protoRequestCached {
 			if protoRequest.Url != nil {
 				requests.PutUrlType(protoRequest.Url)
 			}
@@ -78,7 +119,7 @@ func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (i
 			requests.PutRequest(oProtoRequest)
 
 		}
-		if protoResponse != nil {
+		if protoResponse != nil && !protoResponseCached {
 			if protoResponse.Url != nil {
 				requests.PutUrlType(protoResponse.Url)
 			}
@@ -105,13 +146,17 @@ func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (i
 	}
 	utils.DebugF("Run Xray Poc[%s] for %s", poc.Name, target)
 
-	// 设置原始请求变量
-	oProtoRequest, _ = requests.ParseHttpRequest(oReq)
+	// 设置原始请求变量，tcp/udp没有原始http请求
+	if oReq != nil {
+		oProtoRequest, _ = requests.ParseHttpRequest(oReq)
+	} else {
+		oProtoRequest, _ = requests.ParseTCPUDPRequest(nil)
+	}
 	variableMap["request"] = oProtoRequest
 
 	// 判断transport，如果不合法则跳过
 	transport := poc.Transport
-	if transport == "tcp" || transport == "udp" {
+	if transport == "tcp" || transport == "udp" || transport == "tls" {
 		if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
 			utils.InfoF("Invalid target[%s], skip", target)
 			return
@@ -132,26 +177,21 @@ func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (i
 	if err != nil {
 		wrappedErr := errors.Wrap(err, "Environment creation error")
 		utils.ErrorP(wrappedErr)
-		return false, err
+		return result, err
 	}
 
 	// 请求中的全局变量
 
-	// 定义渲染函数
-	render := func(v string) string {
-		for k1, v1 := range variableMap {
-			_, isMap := v1.(map[string]string)
-			if isMap {
-				continue
-			}
-			v1Value := fmt.Sprintf("%v", v1)
-			t := "{{" + k1 + "}}"
-			if !strings.Contains(v, t) {
-				continue
+	// 定义渲染函数，原地渲染多个字段
+	render := func(fields ...*string) error {
+		for _, field := range fields {
+			value, err := cel.RenderTemplate(*field, variableMap)
+			if err != nil {
+				return err
 			}
-			v = strings.ReplaceAll(v, t, v1Value)
+			*field = value
 		}
-		return v
+		return nil
 	}
 	// 刷新环境
 	ReCreateEnv := func(c *cel.CustomLib) (*cel.Env, error) {
@@ -175,6 +215,28 @@ func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (i
 				continue
 			}
 
+			// 覆盖request.url的path和query，作为后续规则请求的基础地址
+			if k == "request.url.path" || k == "request.url.query" {
+				if oProtoRequest.Url == nil {
+					wrappedErr := errors.Newf(errors.EvaluationError, "Set %s is only supported for http transport", k)
+					utils.ErrorP(wrappedErr)
+					continue
+				}
+				value, ok := out.Value().(string)
+				if !ok {
+					wrappedErr := errors.Newf(errors.EvaluationError, "Set %s must be string, got %T", k, out.Value())
+					utils.ErrorP(wrappedErr)
+					continue
+				}
+				if k == "request.url.path" {
+					oProtoRequest.Url.Path = value
+				} else {
+					oProtoRequest.Url.Query = value
+					urlQueryOverridden = true
+				}
+				continue
+			}
+
 			// 设置variableMap并且更新CompileOption
 			switch value := out.Value().(type) {
 			case *xray_structs.UrlType:
@@ -221,26 +283,55 @@ func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (i
 	// 处理set
 	if err := evaluateUpdateVariableMap(poc.Set); err != nil {
 		utils.ErrorP(err)
-		return false, err
+		return result, err
 	}
 
 	// 渲染detail
-	detail := &poc.Detail
-	detail.Author = render(detail.Author)
-	for k, v := range poc.Detail.Links {
-		detail.Links[k] = render(v)
+	detailFields := []*string{&detail.Author}
+	for k := range detail.Links {
+		detailFields = append(detailFields, &detail.Links[k])
 	}
 	fingerPrint := &detail.FingerPrint
-	for _, info := range fingerPrint.Infos {
-		info.ID = render(info.ID)
-		info.Name = render(info.Name)
-		info.Version = render(info.Version)
-		info.Type = render(info.Type)
+	for k := range fingerPrint.Infos {
+		info := &fingerPrint.Infos[k]
+		detailFields = append(detailFields, &info.ID, &info.Name, &info.Version, &info.Type)
 	}
-	fingerPrint.HostInfo.Hostname = render(fingerPrint.HostInfo.Hostname)
 	vulnerability := &detail.Vulnerability
-	vulnerability.ID = render(vulnerability.ID)
-	vulnerability.Match = render(vulnerability.Match)
+	detailFields = append(detailFields, &fingerPrint.HostInfo.Hostname, &vulnerability.ID, &vulnerability.Match)
+	if err := render(detailFields...); err != nil {
+		wrappedErr := errors.Wrapf(err, "Render poc[%s] detail error", poc.Name)
+		return result, wrappedErr
+	}
+
+	// 规则中使用到的title、body_string等字段才需要计算
+	ruleExpressions := make([]string, 0, len(poc.Rules))
+	outputKeys := make([]string, 0)
+	for _, item := range poc.Rules {
+		ruleExpressions = append(ruleExpressions, item.Value.Expression)
+		for _, output := range item.Value.Output {
+			if expression, ok := output.Value.(string); ok {
+				ruleExpressions = append(ruleExpressions, expression)
+			}
+			if k, ok := output.Key.(string); ok && k != "request.url.path" && k != "request.url.query" {
+				outputKeys = append(outputKeys, k)
+			}
+		}
+	}
+	responseFields := requests.ParseResponseFields(ruleExpressions...)
+
+	// 记录已执行rule的output变量
+	updateExtracted := func() {
+		for _, k := range outputKeys {
+			value, ok := variableMap[k]
+			if !ok {
+				continue
+			}
+			if result.Extracted == nil {
+				result.Extracted = make(map[string]string, len(outputKeys))
+			}
+			addExtracted(result.Extracted, k, value)
+		}
+	}
 
 	// transport=http: request处理
 	HttpRequestInvoke := func(rule xray_structs.Rule) error {
@@ -252,61 +343,145 @@ func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (i
 		)
 
 		// 渲染请求头，请求路径和请求体
+		headers := make(map[string]string, len(ruleReq.Headers))
 		for k, v := range ruleReq.Headers {
-			ruleReq.Headers[k] = render(v)
+			if err = render(&v); err != nil {
+				return errors.Wrapf(err, "Render rule header[%s] error", k)
+			}
+			headers[k] = v
+		}
+		ruleReq.Headers = headers
+		ruleReq.Path = strings.TrimSpace(ruleReq.Path)
+		ruleReq.Body = strings.TrimSpace(ruleReq.Body)
+		ruleReq.Scheme = strings.TrimSpace(ruleReq.Scheme)
+		ruleReq.Host = strings.TrimSpace(ruleReq.Host)
+		ruleReq.Port = strings.TrimSpace(ruleReq.Port)
+		if err = render(&ruleReq.Path, &ruleReq.Body, &ruleReq.Raw, &ruleReq.Scheme, &ruleReq.Host, &ruleReq.Port); err != nil {
+			return errors.Wrapf(err, "Render rule request error")
+		}
+
+		// set可以覆盖基础path和query，缓存需要区分
+		cacheTarget := oReqUrlString + "|" + oProtoRequest.Url.Path
+		if urlQueryOverridden {
+			cacheTarget += "?" + oProtoRequest.Url.Query
 		}
-		ruleReq.Path = render(strings.TrimSpace(ruleReq.Path))
-		ruleReq.Body = render(strings.TrimSpace(ruleReq.Body))
 
 		// 尝试获取缓存
-		if request, protoRequest, protoResponse, ok = requests.XrayGetHttpRequestCache(&ruleReq); !ok || !rule.Request.Cache {
-			// 获取protoRequest
-			protoRequest, err = requests.ParseHttpRequest(oReq)
-			if err != nil {
-				wrappedErr := errors.Wrapf(err, "Run poc[%v] parse request error", poc.Name)
-				return wrappedErr
+		if request, protoRequest, protoResponse, ok = requests.XrayGetHttpRequestCache(cacheTarget, &ruleReq); !ok || !rule.Request.Cache {
+			// 处理scheme、host和port
+			ruleUrl := requests.OverrideUrl(oReq.URL, ruleReq.Scheme, ruleReq.Host, ruleReq.Port)
+
+			// 处理Path和Query，基础path和query可以在set中覆盖
+			rulePath, query := requests.SplitPathQuery(ruleReq.Path)
+			path := oProtoRequest.Url.Path
+			if strings.HasPrefix(rulePath, "/") {
+				path = strings.Trim(path, "/") + "/" + rulePath[1:]
+			} else if strings.HasPrefix(rulePath, "^") {
+				path = "/" + rulePath[1:]
 			}
 
-			// 处理Path
-			if strings.HasPrefix(ruleReq.Path, "/") {
-				protoRequest.Url.Path = strings.Trim(oReq.URL.Path, "/") + "/" + ruleReq.Path[1:]
-			} else if strings.HasPrefix(ruleReq.Path, "^") {
-				protoRequest.Url.Path = "/" + ruleReq.Path[1:]
+			if !strings.HasPrefix(path, "/") {
+				path = "/" + path
 			}
 
-			if !strings.HasPrefix(protoRequest.Url.Path, "/") {
-				protoRequest.Url.Path = "/" + protoRequest.Url.Path
+			if urlQueryOverridden && oProtoRequest.Url.Query != "" {
+				if query != "" {
+					query = oProtoRequest.Url.Query + "&" + query
+				} else {
+					query = oProtoRequest.Url.Query
+				}
 			}
 
-			// 某些poc没有区分path和query，需要处理
-			protoRequest.Url.Path = strings.ReplaceAll(protoRequest.Url.Path, " ", "%20")
-			protoRequest.Url.Path = strings.ReplaceAll(protoRequest.Url.Path, "+", "%20")
-
-			// 克隆请求对象
-			request, err = http.NewRequest(ruleReq.Method, fmt.Sprintf("%s://%s%s", protoRequest.Url.Scheme, protoRequest.Url.Host, protoRequest.Url.Path), strings.NewReader(ruleReq.Body))
-			if err != nil {
-				return err
+			requestUri := path
+			if query != "" {
+				requestUri += "?" + query
 			}
 
-			// 处理请求头
-			request.Header = oReq.Header.Clone()
-			for k, v := range ruleReq.Headers {
-				request.Header.Set(k, v)
-				rawHeaderBuilder.WriteString(k)
-				rawHeaderBuilder.WriteString(": ")
-				rawHeaderBuilder.WriteString(v)
-				rawHeaderBuilder.WriteString("\n")
-			}
+			if ruleReq.Raw != "" || ruleReq.Unsafe {
+				// raw/unsafe模式: 不经过net/http规范化，原样发送请求，不处理cookie和跳转
+				var rawRequest []byte
+				if ruleReq.Raw == "" {
+					rawRequest = requests.BuildRawRequest(ruleReq.Method, requestUri, ruleUrl.Host, oReq.Header, ruleReq.Headers, ruleReq.Body)
+				} else if ruleReq.Unsafe {
+					rawRequest = []byte(ruleReq.Raw)
+				} else {
+					rawRequest = requests.NormalizeRawRequest(ruleReq.Raw)
+				}
 
-			protoRequest.RawHeader = []byte(strings.Trim(rawHeaderBuilder.String(), "\n"))
+				// 获取protoRequest
+				protoRequest, err = requests.ParseRawHttpRequest(ruleUrl, rawRequest)
+				if err != nil {
+					wrappedErr := errors.Wrapf(err, "Run poc[%v] parse raw request error", poc.Name)
+					return wrappedErr
+				}
 
-			// 额外处理protoRequest.Raw
-			protoRequest.Raw, _ = httputil.DumpRequestOut(request, true)
+				// 发起请求
+				request, response, milliseconds, err = requests.DoRawRequest(ruleUrl, protoRequest.Method, rawRequest)
+				if err != nil {
+					metrics.IncrementRequests("xray", "http")
+					return err
+				}
+				metrics.ObserveRequest("xray", "http", milliseconds)
+			} else {
+				// 获取protoRequest
+				protoRequest, err = requests.ParseHttpRequest(oReq)
+				if err != nil {
+					wrappedErr := errors.Wrapf(err, "Run poc[%v] parse request error", poc.Name)
+					return wrappedErr
+				}
+				requests.PutUrlType(protoRequest.Url)
+				protoRequest.Url = requests.ParseUrl(ruleUrl)
+
+				// path中的空格和+需要编码，query中保留+
+				path = strings.ReplaceAll(path, " ", "%20")
+				path = strings.ReplaceAll(path, "+", "%20")
+				query = strings.ReplaceAll(query, " ", "%20")
+				protoRequest.Url.Path = path
+				protoRequest.Url.Query = query
+				protoRequest.Url.Fragment = ""
+
+				requestUri = path
+				if query != "" {
+					requestUri += "?" + query
+				}
 
-			// 发起请求
-			response, milliseconds, err = requests.DoRequest(request, ruleReq.FollowRedirects)
-			if err != nil {
-				return err
+				// 克隆请求对象
+				request, err = http.NewRequest(ruleReq.Method, fmt.Sprintf("%s://%s%s", protoRequest.Url.Scheme, protoRequest.Url.Host, requestUri), strings.NewReader(ruleReq.Body))
+				if err != nil {
+					return err
+				}
+
+				// 处理请求头
+				request.Header = oReq.Header.Clone()
+				for k, v := range ruleReq.Headers {
+					request.Header.Set(k, v)
+					rawHeaderBuilder.WriteString(k)
+					rawHeaderBuilder.WriteString(": ")
+					rawHeaderBuilder.WriteString(v)
+					rawHeaderBuilder.WriteString("\n")
+				}
+
+				// net/http会忽略请求头中的Host
+				if host := request.Header.Get("Host"); host != "" {
+					request.Host = host
+				}
+
+				protoRequest.RawHeader = []byte(strings.Trim(rawHeaderBuilder.String(), "\n"))
+
+				// 额外处理protoRequest.Raw
+				protoRequest.Raw, _ = httputil.DumpRequestOut(request, true)
+
+				// 发起请求
+				if ruleReq.Http2 {
+					response, milliseconds, err = requests.DoHttp2Request(request, ruleReq.FollowRedirects, cookieJar)
+				} else {
+					response, milliseconds, err = requests.DoRequest(request, ruleReq.FollowRedirects, cookieJar)
+				}
+				if err != nil {
+					metrics.IncrementRequests("xray", "http")
+					return err
+				}
+				metrics.ObserveRequest("xray", "http", milliseconds)
 			}
 
 			// 获取protoResponse
@@ -316,11 +491,22 @@ func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (i
 				return wrappedErr
 			}
 
-			// 设置缓存
-			requests.XraySetHttpRequestCache(&ruleReq, request, protoRequest, protoResponse)
+			// 按需计算响应字段
+			requests.FillResponseFields(protoResponse, responseFields)
 
+			// 设置缓存
+			cached := requests.XraySetHttpRequestCache(cacheTarget, &ruleReq, request, protoRequest, protoResponse)
+			protoRequestCached, protoResponseCached = cached, cached
 		} else {
-			utils.DebugF("Hit http request cache[%s%s]", oReqUrlString, ruleReq.Path)
+			utils.DebugF("Hit http request cache[%s%s]", cacheTarget, ruleReq.Path)
+			protoRequestCached, protoResponseCached = true, true
+
+			// 缓存的响应可能被其他协程读取，在副本上计算响应字段
+			if responseFields != 0 {
+				protoResponse = proto.Clone(protoResponse).(*xray_structs.Response)
+				protoResponseCached = false
+				requests.FillResponseFields(protoResponse, responseFields)
+			}
 		}
 
 		return nil
@@ -332,86 +518,149 @@ func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (i
 			tcpudpTypeUpper = strings.ToUpper(tcpudpType)
 			buffer          = BodyBufPool.Get().([]byte)
 
-			content             = rule.Request.Content
-			connectionID string = rule.Request.ConnectionID
+			ruleReq             = rule.Request
+			connectionID string = ruleReq.ConnectionID
 			conn         net.Conn
-			connCache    *net.Conn
+			content      []byte
 			responseRaw  []byte
 			readTimeout  int
+			readUntil    *regexp.Regexp
 
 			ok  bool
 			err error
 		)
 		defer BodyBufPool.Put(buffer)
 
-		// 获取response缓存
-		if responseRaw, protoResponse, ok = requests.XrayGetTcpUdpResponseCache(rule.Request.Content); !ok || !rule.Request.Cache {
-			responseRaw = BodyPool.Get().([]byte)
-			defer BodyPool.Put(responseRaw)
+		// 渲染并解码请求内容
+		if err = render(&ruleReq.Content, &ruleReq.ReadUntil); err != nil {
+			return errors.Wrapf(err, "Render rule request error")
+		}
+		content, err = requests.DecodeContent(ruleReq.Content, ruleReq.ContentEncoding)
+		if err != nil {
+			wrappedErr := errors.Wrapf(err, "Run poc[%s] decode content error", poc.Name)
+			return wrappedErr
+		}
 
-			// 获取connectionID缓存
-			if connCache, ok = requests.XrayGetTcpUdpConnectionCache(connectionID); !ok {
-				// 处理timeout
-				readTimeout, err = strconv.Atoi(rule.Request.ReadTimeout)
+		// 获取response缓存，connection_id的会话依赖之前的请求，不使用缓存
+		isTLS := poc.Transport == "tls" || ruleReq.TLS
+		useCache := connectionID == ""
+		cacheTarget := tcpudpType + "://" + target
+		if useCache {
+			responseRaw, protoResponse, ok = requests.XrayGetTcpUdpResponseCache(cacheTarget, &ruleReq, isTLS, string(content))
+		}
+		if !ok || !ruleReq.Cache {
+			// 处理timeout，未设置时使用默认值
+			readTimeout = DefaultReadTimeout
+			if ruleReq.ReadTimeout != "" {
+				readTimeout, err = strconv.Atoi(ruleReq.ReadTimeout)
 				if err != nil {
-					wrappedErr := errors.Wrapf(err, "Parse read_timeout[%s] to int  error", rule.Request.ReadTimeout)
+					wrappedErr := errors.Wrapf(err, "Parse read_timeout[%s] to int  error", ruleReq.ReadTimeout)
 					return wrappedErr
 				}
+			}
 
-				// 发起连接
-				conn, err = net.Dial(tcpudpType, target)
+			// 处理read_until
+			if ruleReq.ReadUntil != "" {
+				readUntil, err = regexp.Compile(ruleReq.ReadUntil)
 				if err != nil {
-					wrappedErr := errors.Wrapf(err, "%s connect to target[%s] error", tcpudpTypeUpper, target)
+					wrappedErr := errors.Wrapf(err, "Compile read_until[%s] error", ruleReq.ReadUntil)
 					return wrappedErr
 				}
+			}
 
-				// 设置读取超时
-				err := conn.SetReadDeadline(time.Now().Add(time.Duration(readTimeout) * time.Second))
+			// 获取connectionID缓存
+			if conn, ok = connSession.Get(connectionID); !ok {
+				// 发起连接
+				conn, err = requests.Proxies.Dial(tcpudpType, target)
 				if err != nil {
-					wrappedErr := errors.Wrapf(err, "Set read_timeout[%d] error", tcpudpTypeUpper, readTimeout)
+					progress.Progress.IncrementFailedRequestsBy(1)
+					metrics.IncrementRequests("xray", tcpudpType)
+					wrappedErr := errors.Wrapf(err, "%s connect to target[%s] error", tcpudpTypeUpper, target)
 					return wrappedErr
 				}
 
+				// tls握手
+				if isTLS {
+					if tcpudpType == "udp" {
+						conn.Close()
+						return errors.Newf(errors.RequestError, "UDP[%s] doesn't support tls", connectionID)
+					}
+					conn.SetDeadline(time.Now().Add(time.Duration(readTimeout) * time.Second))
+					host, _, _ := net.SplitHostPort(target)
+					tlsConn, err := requests.NewTLSClient(conn, host)
+					if err != nil {
+						conn.Close()
+						progress.Progress.IncrementFailedRequestsBy(1)
+						metrics.IncrementRequests("xray", tcpudpType)
+						wrappedErr := errors.Wrapf(err, "TLS handshake with target[%s] error", target)
+						return wrappedErr
+					}
+					conn = tlsConn
+				}
+				conn = requests.NewPushbackConn(conn)
+
 				// 设置连接缓存
-				requests.XraySetTcpUdpConnectionCache(connectionID, &conn)
+				connSession.Set(connectionID, conn)
 			} else {
-				conn = *connCache
 				utils.DebugF("Hit connection_id cache[%s]", connectionID)
 			}
 
-			// 获取protoRequest
-			protoRequest, _ = requests.ParseTCPUDPRequest([]byte(content))
-
-			// 发送数据
-			_, err = conn.Write([]byte(content))
+			// 每个rule单独设置读取超时，复用连接时同样生效
+			err = conn.SetDeadline(time.Now().Add(time.Duration(readTimeout) * time.Second))
 			if err != nil {
-				wrappedErr := errors.Wrapf(err, "%s[%s] write error", tcpudpTypeUpper, connectionID)
+				wrappedErr := errors.Wrapf(err, "Set read_timeout[%d] error", readTimeout)
 				return wrappedErr
 			}
 
-			// 接收数据
-			for {
-				n, err := conn.Read(buffer)
+			// 获取protoRequest
+			protoRequest, _ = requests.ParseTCPUDPRequest(content)
+
+			if tcpudpType == "udp" {
+				// udp按报文收发，未收到响应时重传
+				retries := requests.DefaultUDPRetries
+				if ruleReq.Retries != nil {
+					retries = *ruleReq.Retries
+				}
+				responseRaw, err = requests.ReadUDP(conn, content, ruleReq.Datagrams, retries, time.Duration(readTimeout)*time.Second)
 				if err != nil {
-					if err == io.EOF {
-					} else if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-					} else {
-						wrappedErr := errors.Wrapf(err, "%s[%s] read error", tcpudpTypeUpper, connectionID)
+					progress.Progress.IncrementFailedRequestsBy(1)
+					wrappedErr := errors.Wrapf(err, "%s[%s] request error", tcpudpTypeUpper, connectionID)
+					return wrappedErr
+				}
+				progress.Progress.IncrementRequests()
+				metrics.IncrementRequests("xray", tcpudpType)
+			} else {
+				// 发送数据，content为空时只读取数据，例如服务端banner
+				if len(content) > 0 {
+					_, err = conn.Write(content)
+					if err != nil {
+						progress.Progress.IncrementFailedRequestsBy(1)
+						wrappedErr := errors.Wrapf(err, "%s[%s] write error", tcpudpTypeUpper, connectionID)
 						return wrappedErr
 					}
-					break
 				}
-				responseRaw = append(responseRaw, buffer[:n]...)
+
+				progress.Progress.IncrementRequests()
+				metrics.IncrementRequests("xray", tcpudpType)
+
+				// 接收数据
+				responseRaw, err = requests.ReadTCPUDP(conn, buffer, ruleReq.ReadSize, readUntil)
+				if err != nil {
+					// 请求已计数，只记录错误
+					progress.Progress.IncrementErrorsBy(1)
+					wrappedErr := errors.Wrapf(err, "%s[%s] read error", tcpudpTypeUpper, connectionID)
+					return wrappedErr
+				}
 			}
 
 			// 获取protoResponse
 			protoResponse, _ = requests.ParseTCPUDPResponse(responseRaw, &conn, tcpudpType)
 
 			// 设置响应缓存
-			requests.XraySetTcpUdpResponseCache(content, responseRaw, protoResponse)
-
+			protoResponseCached = useCache && requests.XraySetTcpUdpResponseCache(cacheTarget, &ruleReq, isTLS, string(content), responseRaw, protoResponse)
 		} else {
 			utils.DebugF("Hit tcp/udp request cache[%s]", responseRaw)
+			protoResponseCached = true
 		}
 
 		return nil
@@ -432,8 +681,8 @@ func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (i
 		variableMap["request"] = protoRequest
 		variableMap["response"] = protoResponse
 
-		utils.DebugF("raw requests: \n%#s", string(protoRequest.Raw))
-		utils.DebugF("raw response: \n%#s", string(protoResponse.Raw))
+		utils.DebugF("raw requests: \n%s", string(protoRequest.Raw))
+		utils.DebugF("raw response: \n%s", string(protoResponse.Raw))
 
 		// 执行表达式
 		out, err := cel.Evaluate(globalEnv, rule.Expression, variableMap)
@@ -458,7 +707,7 @@ func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (i
 	}
 
 	// 判断transport类型，设置requestInvoke
-	if poc.Transport == "tcp" {
+	if poc.Transport == "tcp" || poc.Transport == "tls" {
 		tcpudpType = "tcp"
 		requestFunc = TCPUDPRequestInvoke
 	} else if poc.Transport == "udp" {
@@ -474,14 +723,14 @@ func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (i
 		c.DefineRuleFunction(requestFunc, ruleItem.Key, ruleItem.Value, RequestInvoke)
 		if err != nil {
 			wrappedErr := errors.Wrapf(err, "Define %s error", ruleItem.Key)
-			return false, wrappedErr
+			return result, wrappedErr
 		}
 	}
 	// ? 最后再生成一遍环境，否则之前增加的变量定义不生效
 	globalEnv, err = ReCreateEnv(c)
 	if err != nil {
 		utils.ErrorP(err)
-		return false, err
+		return result, err
 	}
 
 	// 执行rule 并判断poc总体表达式结果
@@ -502,23 +751,75 @@ func executeXrayPoc(oReq *http.Request, target string, poc *xray_structs.Poc) (i
 
 	// 如果没设置payload，则直接评估rules并返回
 	if len(poc.Payloads.Payloads) == 0 {
-		return run()
+		result.IsVul, err = run()
+		if result.IsVul {
+			updateExtracted()
+		}
+		return result, err
 	}
 
-	// 如果设置了payload，则遍历执行
-	isVul = false
-
-	for _, setMapVal := range poc.Payloads.Payloads {
-		payloads := setMapVal.Value.(yaml.MapSlice)
-		evaluateUpdateVariableMap(payloads)
-		isVul, err = run()
+	// 执行一组payload，记录命中的payload，返回是否继续执行
+	runPayload := func(set yaml.MapSlice) (bool, error) {
+		isVul, err := run()
 		if err != nil {
 			return false, err
 		}
 
-		if isVul && !poc.Payloads.Continue {
-			return isVul, nil
+		if isVul {
+			result.IsVul = true
+			updateExtracted()
+			payload := make(yaml.MapSlice, 0, len(set))
+			for _, item := range set {
+				payload = append(payload, yaml.MapItem{Key: item.Key, Value: fmt.Sprintf("%v", variableMap[item.Key.(string)])})
+			}
+			result.Payloads = append(result.Payloads, payload)
 		}
+
+		return !isVul || poc.Payloads.Continue, nil
 	}
-	return isVul, nil
+
+	// 如果设置了payload，则遍历执行
+	if poc.Payloads.Mode == "" {
+		for _, setMapVal := range poc.Payloads.Payloads {
+			payloads := setMapVal.Value.(yaml.MapSlice)
+			if err := evaluateUpdateVariableMap(payloads); err != nil {
+				return result, err
+			}
+			if next, err := runPayload(payloads); err != nil {
+				return result, err
+			} else if !next {
+				break
+			}
+		}
+		return result, nil
+	}
+
+	// cartesian/pitchfork模式下payload为字面量
+	poc.Payloads.Range(func(set yaml.MapSlice) bool {
+		if IsStopped() {
+			return false
+		}
+
+		// 只在第一组payload定义变量并重新生成环境，之后只更新variableMap
+		declared := false
+		for _, item := range set {
+			k := item.Key.(string)
+			if _, ok := variableMap[k]; !ok {
+				c.UpdateCompileOption(k, decls.String)
+				declared = true
+			}
+			variableMap[k] = item.Value
+		}
+		if declared {
+			if globalEnv, err = ReCreateEnv(c); err != nil {
+				return false
+			}
+		}
+
+		var next bool
+		next, err = runPayload(set)
+		return err == nil && next
+	})
+
+	return result, err
 }
@@ -0,0 +1,421 @@
+package check
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/WAY29/pocV/pkg/xray/requests"
+	xray_structs "github.com/WAY29/pocV/pkg/xray/structs"
+	"github.com/WAY29/pocV/utils"
+	"github.com/projectdiscovery/nuclei/v2/pkg/output"
+	"gopkg.in/yaml.v2"
+)
+
+const racePoc = `
+name: poc-yaml-race-test
+transport: http
+set:
+  tag: request.url.path
+rules:
+  r0:
+    request:
+      cache: true
+      method: GET
+      path: /echo?tag={{tag|urlencode}}
+      headers:
+        X-Target: '{{tag}}'
+    expression: |
+      response.title == tag && response.body_string.contains("X-Target: " + tag)
+    output:
+      m: '"tag=(?P<tag>[^&\\s]+)".submatch(response.body_string)'
+  r1:
+    request:
+      method: POST
+      path: /echo
+      body: 'tag={{m.tag}}'
+    expression: |
+      response.body_string.contains("body: tag=" + urlencode(tag))
+expression: r0() && r1()
+detail:
+  author: '{{tag}}'
+  links:
+    - '{{request.url.host}}{{tag}}'
+  fingerprint:
+    infos:
+      - id: '{{tag|upper}}'
+`
+
+func TestMain(m *testing.M) {
+	utils.InitLog(false, false)
+	if err := requests.InitHttpClient(10, nil, 5*time.Second, false, false, 1<<20, false); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	requests.InitCache(1024)
+
+	os.Exit(m.Run())
+}
+
+func newEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		target := r.Header.Get("X-Target")
+		fmt.Fprintf(w, "<title>%s</title>\nX-Target: %s\npath: %s?%s\nbody: %s\n", target, target, r.URL.Path, r.URL.RawQuery, body)
+	}))
+}
+
+func TestExecuteXrayPocConcurrent(t *testing.T) {
+	const targetsNum = 50
+
+	server := newEchoServer()
+	defer server.Close()
+
+	var poc xray_structs.Poc
+	if err := yaml.Unmarshal([]byte(racePoc), &poc); err != nil {
+		t.Fatal(err)
+	}
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	// 第二轮命中缓存
+	for round := 0; round < 2; round++ {
+		var wg sync.WaitGroup
+
+		for i := 0; i < targetsNum; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				target := fmt.Sprintf("%s/t%d", server.URL, i)
+				tag := fmt.Sprintf("/t%d", i)
+				oReq, _ := http.NewRequest("GET", target, nil)
+
+				// 与Task一致，使用poc的浅拷贝
+				task := xray_structs.Task{Target: target, Poc: poc}
+				result, err := executeXrayPoc(oReq, task.Target, &task.Poc)
+				if err != nil {
+					t.Errorf("target %s error: %v", target, err)
+					return
+				}
+				detail := result.Detail
+				if !result.IsVul {
+					t.Errorf("target %s should be vulnerable", target)
+				}
+				if detail.Author != tag {
+					t.Errorf("target %s got author %s", target, detail.Author)
+				}
+				if len(detail.Links) != 1 || detail.Links[0] != host+tag {
+					t.Errorf("target %s got links %v", target, detail.Links)
+				}
+				if len(detail.FingerPrint.Infos) != 1 || detail.FingerPrint.Infos[0].ID != strings.ToUpper(tag) {
+					t.Errorf("target %s got infos %v", target, detail.FingerPrint.Infos)
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	// 共享的poc不应被修改
+	if poc.Detail.Author != "{{tag}}" || poc.Detail.Links[0] != "{{request.url.host}}{{tag}}" || poc.Detail.FingerPrint.Infos[0].ID != "{{tag|upper}}" {
+		t.Errorf("shared poc detail was modified: %+v", poc.Detail)
+	}
+	if poc.Rules[0].Value.Request.Headers["X-Target"] != "{{tag}}" || poc.Rules[0].Value.Request.Path != "/echo?tag={{tag|urlencode}}" {
+		t.Errorf("shared poc request was modified: %+v", poc.Rules[0].Value.Request)
+	}
+}
+
+const urlOverridePoc = `
+name: poc-yaml-url-override-test
+transport: http
+set:
+  %s: '"%s"'
+rules:
+  r0:
+    request:
+      cache: true
+      method: GET
+      path: /echo
+    expression: |
+      response.body_string.contains("path: %s")
+expression: r0()
+`
+
+func TestHttpCacheIsolatedByUrlOverride(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+
+	// 同一target上仅set覆盖的path或query不同的poc不应命中同一缓存
+	tests := []struct {
+		key, value, want string
+	}{
+		{"request.url.path", "/override-a", "/override-a/echo?"},
+		{"request.url.path", "/override-b", "/override-b/echo?"},
+		{"request.url.query", "q=a", "/echo?q=a"},
+		{"request.url.query", "q=b", "/echo?q=b"},
+	}
+
+	target := server.URL + "/"
+	for _, tt := range tests {
+		var poc xray_structs.Poc
+		if err := yaml.Unmarshal([]byte(fmt.Sprintf(urlOverridePoc, tt.key, tt.value, tt.want)), &poc); err != nil {
+			t.Fatal(err)
+		}
+		oReq, _ := http.NewRequest("GET", target, nil)
+		result, err := executeXrayPoc(oReq, target, &poc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.IsVul {
+			t.Errorf("set %s=%s: response of another poc was returned from cache", tt.key, tt.value)
+		}
+	}
+}
+
+func TestHttpCacheIsolatedByTarget(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+
+	var poc xray_structs.Poc
+	if err := yaml.Unmarshal([]byte(racePoc), &poc); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/cache-a", "/cache-b"} {
+		target := server.URL + path
+		oReq, _ := http.NewRequest("GET", target, nil)
+		p := poc
+		result, err := executeXrayPoc(oReq, target, &p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.IsVul || result.Detail.Author != path {
+			t.Errorf("target %s got vul %v author %s", target, result.IsVul, result.Detail.Author)
+		}
+	}
+}
+
+func TestAddExtracted(t *testing.T) {
+	extracted := make(map[string]string)
+	addExtracted(extracted, "m", map[string]string{"tag": "a", "id": "1"})
+	addExtracted(extracted, "body", []byte("bytes"))
+	addExtracted(extracted, "count", int64(2))
+	addExtracted(extracted, "s", "string")
+	addExtracted(extracted, "reverse", &xray_structs.Reverse{})
+
+	want := map[string]string{"m.tag": "a", "m.id": "1", "body": "bytes", "count": "2", "s": "string"}
+	if len(extracted) != len(want) {
+		t.Errorf("got extracted %v, want %v", extracted, want)
+	}
+	for k, v := range want {
+		if extracted[k] != v {
+			t.Errorf("extracted[%s] = %q, want %q", k, extracted[k], v)
+		}
+	}
+}
+
+func TestFormatExtracted(t *testing.T) {
+	tests := []struct {
+		extracted map[string]string
+		want      string
+	}{
+		{nil, ""},
+		{map[string]string{}, ""},
+		{map[string]string{"m.tag": "a"}, " {m.tag=a}"},
+		{map[string]string{"b": "2", "a": "1", "m.tag": "x"}, " {a=1, b=2, m.tag=x}"},
+		// nuclei extractor的结果
+		{nucleiExtracted(&output.ResultEvent{ExtractorName: "version", ExtractedResults: []string{"1.0", "2.0"}}), " {version=1.0, 2.0}"},
+		{nucleiExtracted(&output.ResultEvent{ExtractedResults: []string{"token"}}), " {extracted=token}"},
+		{nucleiExtracted(&output.ResultEvent{ExtractorName: "version"}), ""},
+	}
+
+	for _, tt := range tests {
+		if got := formatExtracted(tt.extracted); got != tt.want {
+			t.Errorf("formatExtracted(%v) = %q, want %q", tt.extracted, got, tt.want)
+		}
+	}
+}
+
+func TestExecuteXrayPocExtracted(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+
+	target := server.URL + "/extracted"
+	execute := func(pocContent string) xrayPocResult {
+		var poc xray_structs.Poc
+		if err := yaml.Unmarshal([]byte(pocContent), &poc); err != nil {
+			t.Fatal(err)
+		}
+		oReq, _ := http.NewRequest("GET", target, nil)
+		result, err := executeXrayPoc(oReq, target, &poc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	// output中submatch的结果按变量名展开
+	result := execute(racePoc)
+	if tag := url.QueryEscape("/extracted"); !result.IsVul || len(result.Extracted) != 1 || result.Extracted["m.tag"] != tag {
+		t.Errorf("got vul %v extracted %v, want m.tag=%s", result.IsVul, result.Extracted, tag)
+	}
+
+	// 未命中的poc不输出已执行rule的output
+	result = execute(strings.Replace(racePoc, "expression: r0() && r1()", "expression: r0() && !r1()", 1))
+	if result.IsVul || result.Extracted != nil {
+		t.Errorf("got vul %v extracted %v, want no extracted", result.IsVul, result.Extracted)
+	}
+}
+
+const payloadsPoc = `
+name: poc-yaml-payloads-test
+transport: http
+payloads:
+  mode: cartesian
+  continue: true
+  payloads:
+    user: [admin, root]
+    pass: [x, secret]
+rules:
+  r0:
+    request:
+      method: GET
+      path: /echo?u={{user}}&p={{pass}}
+    expression: response.body_string.contains("u=root&p=secret") || response.body_string.contains("u=admin&p=x")
+expression: r0()
+`
+
+func TestExecuteXrayPocPayloads(t *testing.T) {
+	var requested int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requested, 1)
+		fmt.Fprintf(w, "path: %s?%s\n", r.URL.Path, r.URL.RawQuery)
+	}))
+	defer server.Close()
+
+	execute := func() xrayPocResult {
+		var poc xray_structs.Poc
+		if err := yaml.Unmarshal([]byte(payloadsPoc), &poc); err != nil {
+			t.Fatal(err)
+		}
+		// 取值列表在解析poc时加载
+		poc.Payloads.Variables = []xray_structs.PayloadVariable{
+			{Name: "user", Values: []string{"admin", "root"}},
+			{Name: "pass", Values: []string{"x", "secret"}},
+		}
+		oReq, _ := http.NewRequest("GET", server.URL, nil)
+		result, err := executeXrayPoc(oReq, server.URL, &poc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	result := execute()
+	if !result.IsVul || len(result.Payloads) != 2 {
+		t.Fatalf("got vul %v payloads %v, want 2 payloads", result.IsVul, result.Payloads)
+	}
+	for i, want := range []string{"admin:x", "root:secret"} {
+		payload := result.Payloads[i]
+		if got := fmt.Sprintf("%v:%v", payload[0].Value, payload[1].Value); got != want {
+			t.Errorf("payload %d = %s, want %s", i, got, want)
+		}
+	}
+	if got := atomic.LoadInt32(&requested); got != 4 {
+		t.Errorf("requested %d times, want 4", got)
+	}
+
+	// 停止后不再执行剩余的payload
+	atomic.StoreInt32(&stopped, 1)
+	defer atomic.StoreInt32(&stopped, 0)
+	atomic.StoreInt32(&requested, 0)
+	if result := execute(); result.IsVul || atomic.LoadInt32(&requested) != 0 {
+		t.Errorf("got vul %v requested %d times after stopped", result.IsVul, atomic.LoadInt32(&requested))
+	}
+}
+
+const connectionPoc = `
+name: poc-yaml-connection-test
+transport: tcp
+rules:
+  r0:
+    request:
+      connection_id: c
+      content: "a\n"
+      read_until: '\n'
+    expression: response.raw.bcontains(b"a msg 1")
+  r1:
+    request:
+      cache: true
+      connection_id: c
+      content: "b\n"
+      read_until: '\n'
+    expression: response.raw.bcontains(b"b msg 2")
+expression: r0() && r1()
+`
+
+const contentPoc = `
+name: poc-yaml-content-test
+transport: tcp
+rules:
+  r0:
+    request:
+      cache: true
+      content: "b\n"
+      read_until: '\n'
+    expression: response.raw.bcontains(b"b msg 1")
+expression: r0()
+`
+
+func TestTcpCacheSkipsConnectionID(t *testing.T) {
+	// 每个连接单独计数，回复收到的内容和序号
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for i := 1; ; i++ {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					fmt.Fprintf(conn, "%s msg %d\n", strings.TrimSpace(line), i)
+				}
+			}(conn)
+		}
+	}()
+	target := listener.Addr().String()
+
+	// connection_id会话中的响应不写入缓存，其他poc发送相同内容时重新请求
+	for _, pocContent := range []string{connectionPoc, contentPoc} {
+		var poc xray_structs.Poc
+		if err := yaml.Unmarshal([]byte(pocContent), &poc); err != nil {
+			t.Fatal(err)
+		}
+		result, err := executeXrayPoc(nil, target, &poc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.IsVul {
+			t.Errorf("poc %s should be vulnerable", poc.Name)
+		}
+	}
+}
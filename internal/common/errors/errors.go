@@ -20,8 +20,41 @@ const (
 	ResponseError
 	FileError
 	FileNotFoundError
+	ConfigError
+	TemplateError
 )
 
+var errorTypeNames = [...]string{
+	Unknown:                "Unknown",
+	ConvertInterfaceError:  "ConvertInterfaceError",
+	EnvInitializationError: "EnvInitializationError",
+	CompileError:           "CompileError",
+	ProgramCreationError:   "ProgramCreationError",
+	EvaluationError:        "EvaluationError",
+	ProxyError:             "ProxyError",
+	RequestError:           "RequestError",
+	ResponseError:          "ResponseError",
+	FileError:              "FileError",
+	FileNotFoundError:      "FileNotFoundError",
+	ConfigError:            "ConfigError",
+	TemplateError:          "TemplateError",
+}
+
+func (t ErrorType) String() string {
+	if int(t) < len(errorTypeNames) {
+		return errorTypeNames[t]
+	}
+	return errorTypeNames[Unknown]
+}
+
+// 获取错误类型，非CustomError返回Unknown
+func TypeOf(err error) ErrorType {
+	if customErr, ok := errors.Cause(err).(CustomError); ok {
+		return customErr.Type
+	}
+	return Unknown
+}
+
 type CustomError struct {
 	Type ErrorType
 	Msg  string
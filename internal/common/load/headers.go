@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/WAY29/pocV/utils"
+)
+
+// 读取自定义请求头，包括-H、请求头文件、cookie和认证信息
+func LoadHeaders(headers *[]string, headerFiles *[]string, cookie string, auth string) http.Header {
+	header := make(http.Header)
+	lines := make([]string, 0, len(*headers))
+
+	for _, headerFile := range *headerFiles {
+		if utils.Exists(headerFile) && utils.IsFile(headerFile) {
+			utils.DebugF("Load header file: %v", headerFile)
+
+			lineSlice, err := utils.ReadFileAsLine(headerFile)
+			if err != nil {
+				utils.CliError("Read header file error: "+err.Error(), 2)
+			}
+			lines = append(lines, lineSlice...)
+		} else {
+			utils.WarningF("Header file not found: %v", headerFile)
+		}
+	}
+	// 命令行中的请求头优先级高于文件
+	lines = append(lines, *headers...)
+
+	for _, line := range lines {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			utils.CliError("Invalid header: "+line, 2)
+		}
+		header.Set(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+
+	if cookie != "" {
+		header.Set("Cookie", cookie)
+	}
+
+	if auth != "" {
+		authType, credential := auth, ""
+		if i := strings.Index(auth, ":"); i != -1 {
+			authType, credential = auth[:i], auth[i+1:]
+		}
+
+		switch strings.ToLower(authType) {
+		case "basic":
+			if !strings.Contains(credential, ":") {
+				utils.CliError("Invalid basic auth, should be basic:<username>:<password>", 2)
+			}
+			header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credential)))
+		case "bearer":
+			if credential == "" {
+				utils.CliError("Invalid bearer auth, should be bearer:<token>", 2)
+			}
+			header.Set("Authorization", "Bearer "+credential)
+		default:
+			utils.CliError("Unsupported auth type: "+authType, 2)
+		}
+	}
+
+	if len(header) > 0 {
+		utils.InfoF("Load [%d] custom header(s)", len(header))
+	}
+
+	return header
+}
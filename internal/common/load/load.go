@@ -38,6 +38,38 @@ func LoadTargets(target *[]string, targetFiles *[]string) []string {
 	return targetsSlice
 }
 
+// 读取代理
+func LoadProxies(proxy string, proxyFiles *[]string) []string {
+	proxiesSlice := make([]string, 0)
+	if proxy != "" {
+		proxiesSlice = append(proxiesSlice, proxy)
+	}
+
+	for _, proxyFile := range *proxyFiles {
+		if utils.Exists(proxyFile) && utils.IsFile(proxyFile) {
+			utils.DebugF("Load proxy file: %v", proxyFile)
+
+			lineSlice, err := utils.ReadFileAsLine(proxyFile)
+			if err != nil {
+				utils.CliError("Read proxy file error: "+err.Error(), 2)
+			}
+			for _, line := range lineSlice {
+				if line != "" && !strings.HasPrefix(line, "#") {
+					proxiesSlice = append(proxiesSlice, line)
+				}
+			}
+		} else {
+			utils.WarningF("Proxy file not found: %v", proxyFile)
+		}
+	}
+
+	if len(proxiesSlice) > 0 {
+		utils.InfoF("Load [%d] proxy(s)", len(proxiesSlice))
+	}
+
+	return proxiesSlice
+}
+
 // 读取pocs
 func LoadPocs(pocs *[]string, pocPaths *[]string) (map[string]xray_structs.Poc, map[string]nuclei_structs.Poc) {
 	xrayPocMap := make(map[string]xray_structs.Poc)
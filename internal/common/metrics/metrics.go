@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/WAY29/pocV/internal/common/errors"
+	"github.com/projectdiscovery/nuclei/v2/pkg/progress"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pocv"
+
+var (
+	Registry = prometheus.NewRegistry()
+
+	RequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "Number of requests sent, by engine and transport.",
+	}, []string{"engine", "transport"})
+
+	RequestLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_latency_milliseconds",
+		Help:      "Time to first response byte in milliseconds.",
+		Buckets:   []float64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 20000},
+	}, []string{"engine", "transport"})
+
+	CacheHitsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_hits_total",
+		Help:      "Number of xray request cache hits, by cache.",
+	}, []string{"cache"})
+
+	CacheMissesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_misses_total",
+		Help:      "Number of xray request cache misses, by cache.",
+	}, []string{"cache"})
+
+	ReversePollsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reverse_polls_total",
+		Help:      "Number of reverse platform polls, by platform.",
+	}, []string{"platform"})
+
+	MatchesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "matches_total",
+		Help:      "Number of vulnerabilities found, by engine.",
+	}, []string{"engine"})
+
+	ErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "errors_total",
+		Help:      "Number of errors, by error type.",
+	}, []string{"type"})
+)
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		RequestsTotal,
+		RequestLatency,
+		CacheHitsTotal,
+		CacheMissesTotal,
+		ReversePollsTotal,
+		MatchesTotal,
+		ErrorsTotal,
+	)
+}
+
+// 启动metrics服务，地址为空时不启动
+func InitMetrics(listen string) error {
+	if listen == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return errors.Wrapf(err, "Listen metrics address[%s] error", listen)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+	go http.Serve(listener, mux)
+
+	return nil
+}
+
+func ObserveRequest(engine, transport string, milliseconds int64) {
+	RequestsTotal.WithLabelValues(engine, transport).Inc()
+	RequestLatency.WithLabelValues(engine, transport).Observe(float64(milliseconds))
+}
+
+func IncrementRequests(engine, transport string) {
+	RequestsTotal.WithLabelValues(engine, transport).Inc()
+}
+
+func ObserveCache(cache string, hit bool) {
+	if hit {
+		CacheHitsTotal.WithLabelValues(cache).Inc()
+	} else {
+		CacheMissesTotal.WithLabelValues(cache).Inc()
+	}
+}
+
+func IncrementReversePolls(platform string) {
+	ReversePollsTotal.WithLabelValues(platform).Inc()
+}
+
+func IncrementMatches(engine string) {
+	MatchesTotal.WithLabelValues(engine).Inc()
+}
+
+func ObserveError(err error) {
+	ErrorsTotal.WithLabelValues(errors.TypeOf(err).String()).Inc()
+}
+
+// 包装nuclei的progress，额外记录nuclei的请求数与错误数
+type NucleiProgress struct {
+	progress.Progress
+}
+
+func (p *NucleiProgress) IncrementRequests() {
+	IncrementRequests("nuclei", "template")
+	p.Progress.IncrementRequests()
+}
+
+func (p *NucleiProgress) IncrementErrorsBy(count int64) {
+	ErrorsTotal.WithLabelValues(errors.RequestError.String()).Add(float64(count))
+	p.Progress.IncrementErrorsBy(count)
+}
+
+func (p *NucleiProgress) IncrementFailedRequestsBy(count int64) {
+	RequestsTotal.WithLabelValues("nuclei", "template").Add(float64(count))
+	ErrorsTotal.WithLabelValues(errors.RequestError.String()).Add(float64(count))
+	p.Progress.IncrementFailedRequestsBy(count)
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"github.com/WAY29/pocV/internal/common/errors"
+	"github.com/WAY29/pocV/internal/common/progress"
 	"github.com/WAY29/pocV/pkg/common/structs"
 	"github.com/WAY29/pocV/utils"
 
@@ -40,11 +41,19 @@ func InitOutput(file string, jsonFlag, successFlag bool) (chan structs.Result, *
 
 	go func() {
 		defer outputWg.Done()
+		// 通道关闭后刷新并关闭所有输出
+		defer func() {
+			for _, output := range outputs {
+				output.Close()
+			}
+		}()
 
 		for result := range outputChannel {
 			if successFlag && !result.SUCCESS() {
 				continue
 			}
+			// 清除进度状态行
+			progress.Progress.Clear()
 			for _, output := range outputs {
 				output.Write(result)
 			}
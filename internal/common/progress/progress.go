@@ -0,0 +1,226 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+// 同时被xray和nuclei使用的进度统计，实现了nuclei的progress.Progress接口
+type ScanProgress struct {
+	tasksTotal int64
+	tasksDone  int64
+	requests   int64
+	matched    int64
+	errors     int64 // 失败的请求数，执行失败的任务由Summary统计
+
+	startTime  time.Time
+	statusLine bool
+	statsJson  bool
+	interval   time.Duration
+
+	mutex       sync.Mutex
+	lineDrawn   bool
+	stopChannel chan struct{}
+	stopOnce    sync.Once
+	wg          sync.WaitGroup
+}
+
+// 进度统计的快照，用于输出json
+type Stats struct {
+	TasksTotal int64   `json:"tasks_total"`
+	TasksDone  int64   `json:"tasks_done"`
+	Percent    float64 `json:"percent"`
+	Requests   int64   `json:"requests"`
+	Matched    int64   `json:"matched"`
+	Errors     int64   `json:"errors"`
+	RPS        float64 `json:"rps"`
+	Elapsed    string  `json:"elapsed"`
+	ETA        string  `json:"eta"`
+}
+
+var (
+	// 未初始化时只计数，不输出
+	Progress = &ScanProgress{startTime: time.Now()}
+)
+
+// 初始化进度统计，终端下输出状态行，statsJson为true时定期输出json统计行
+func InitProgress(statsJson, statusLine bool, interval time.Duration) *ScanProgress {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	Progress = &ScanProgress{
+		startTime:   time.Now(),
+		statsJson:   statsJson,
+		statusLine:  statusLine && !statsJson && isatty.IsTerminal(os.Stderr.Fd()),
+		interval:    interval,
+		stopChannel: make(chan struct{}),
+	}
+
+	return Progress
+}
+
+// 设置任务总数并开始定期输出
+func (p *ScanProgress) Start(tasksTotal int64) {
+	atomic.StoreInt64(&p.tasksTotal, tasksTotal)
+	p.startTime = time.Now()
+
+	if !p.statusLine && !p.statsJson {
+		return
+	}
+
+	tickDuration := p.interval
+	if p.statusLine {
+		tickDuration = time.Second
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(tickDuration)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.render()
+			case <-p.stopChannel:
+				return
+			}
+		}
+	}()
+}
+
+// 停止输出，json模式下输出最后一次统计
+func (p *ScanProgress) Stop() {
+	p.stopOnce.Do(func() {
+		if p.stopChannel == nil {
+			return
+		}
+		close(p.stopChannel)
+		p.wg.Wait()
+
+		if p.statsJson {
+			p.render()
+		}
+		p.Clear()
+	})
+}
+
+// 清除状态行，在输出结果前调用，避免与结果混在同一行
+func (p *ScanProgress) Clear() {
+	if !p.statusLine {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.lineDrawn {
+		fmt.Fprint(os.Stderr, "\r\033[2K")
+		p.lineDrawn = false
+	}
+}
+
+// nuclei在Runner中调用，这里的任务总数由Start设置
+func (p *ScanProgress) Init(hostCount int64, rulesCount int, requestCount int64) {}
+
+// nuclei的请求总数与任务总数无关，不做处理
+func (p *ScanProgress) AddToTotal(delta int64) {}
+
+func (p *ScanProgress) IncrementRequests() {
+	atomic.AddInt64(&p.requests, 1)
+}
+
+func (p *ScanProgress) IncrementMatched() {
+	atomic.AddInt64(&p.matched, 1)
+}
+
+func (p *ScanProgress) IncrementErrorsBy(count int64) {
+	atomic.AddInt64(&p.errors, count)
+}
+
+func (p *ScanProgress) IncrementFailedRequestsBy(count int64) {
+	atomic.AddInt64(&p.requests, count)
+	atomic.AddInt64(&p.errors, count)
+}
+
+func (p *ScanProgress) IncrementTasksDone() {
+	atomic.AddInt64(&p.tasksDone, 1)
+}
+
+// 获取当前统计
+func (p *ScanProgress) Stats() Stats {
+	var (
+		eta     time.Duration
+		percent float64
+		rps     float64
+	)
+
+	total := atomic.LoadInt64(&p.tasksTotal)
+	done := atomic.LoadInt64(&p.tasksDone)
+	requests := atomic.LoadInt64(&p.requests)
+	elapsed := time.Since(p.startTime)
+
+	if total > 0 {
+		percent = float64(done) * 100 / float64(total)
+	}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		rps = float64(requests) / seconds
+	}
+	if done > 0 && total > done {
+		eta = time.Duration(float64(elapsed) / float64(done) * float64(total-done))
+	}
+
+	return Stats{
+		TasksTotal: total,
+		TasksDone:  done,
+		Percent:    percent,
+		Requests:   requests,
+		Matched:    atomic.LoadInt64(&p.matched),
+		Errors:     atomic.LoadInt64(&p.errors),
+		RPS:        rps,
+		Elapsed:    formatDuration(elapsed),
+		ETA:        formatDuration(eta),
+	}
+}
+
+func (p *ScanProgress) render() {
+	stats := p.Stats()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.statsJson {
+		if js, err := json.Marshal(stats); err == nil {
+			fmt.Fprintln(os.Stderr, string(js))
+		}
+		return
+	}
+
+	var builder strings.Builder
+	builder.WriteString("\r\033[2K")
+	builder.WriteString(fmt.Sprintf("[%s] Tasks: %d/%d (%.0f%%) | Requests: %d | Matched: %d | Errors: %d | RPS: %.0f | ETA: %s",
+		stats.Elapsed, stats.TasksDone, stats.TasksTotal, stats.Percent, stats.Requests, stats.Matched, stats.Errors, stats.RPS, stats.ETA))
+	fmt.Fprint(os.Stderr, builder.String())
+	p.lineDrawn = true
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	d -= m * time.Minute
+	s := d / time.Second
+
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+}
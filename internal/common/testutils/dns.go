@@ -0,0 +1,52 @@
+// 测试用的辅助函数，只在_test.go中引用
+package testutils
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// 对所有A查询返回ip的dns服务器，返回地址和查询次数
+func NewDNSServer(t *testing.T, ip string) (string, *int64) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var queries int64
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var msg dnsmessage.Message
+			if err := msg.Unpack(buf[:n]); err != nil || len(msg.Questions) == 0 {
+				continue
+			}
+			atomic.AddInt64(&queries, 1)
+
+			msg.Header.Response = true
+			msg.Header.RCode = dnsmessage.RCodeSuccess
+			question := msg.Questions[0]
+			if question.Type == dnsmessage.TypeA {
+				var a [4]byte
+				copy(a[:], net.ParseIP(ip).To4())
+				msg.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &dnsmessage.AResource{A: a},
+				}}
+			}
+			if packed, err := msg.Pack(); err == nil {
+				conn.WriteTo(packed, addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String(), &queries
+}
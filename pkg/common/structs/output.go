@@ -9,6 +9,7 @@ import (
 
 type Output interface {
 	Write(result Result)
+	Close()
 }
 
 // StandardOutput
@@ -22,6 +23,8 @@ func (o *StandardOutput) Write(result Result) {
 	}
 }
 
+func (o *StandardOutput) Close() {}
+
 // FileOutput
 type FileOutput struct {
 	F    *os.File
@@ -48,3 +51,14 @@ func (o *FileOutput) Write(result Result) {
 	}
 
 }
+
+func (o *FileOutput) Close() {
+	if err := o.F.Sync(); err != nil {
+		wrappedErr := errors.Newf(errors.FileError, "Can't sync file '%s': %#v", o.F.Name(), err)
+		utils.ErrorP(wrappedErr)
+	}
+	if err := o.F.Close(); err != nil {
+		wrappedErr := errors.Newf(errors.FileError, "Can't close file '%s': %#v", o.F.Name(), err)
+		utils.ErrorP(wrappedErr)
+	}
+}
@@ -16,6 +16,10 @@ type PocResult struct {
 	PocLink        []string `json:"poc_link"`
 	PocAuthor      string   `json:"poc_author"`
 	PocDescription string   `json:"poc_description"`
+	// 命中的payload
+	Payloads []map[string]string `json:"payloads,omitempty"`
+	// 提取的变量
+	Extracted map[string]string `json:"extracted,omitempty"`
 }
 
 func (r *PocResult) JSON() string {
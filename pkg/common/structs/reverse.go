@@ -2,6 +2,7 @@ package structs
 
 import (
 	"net/http"
+	"net/http/cookiejar"
 	"strings"
 	"time"
 
@@ -14,6 +15,8 @@ var (
 	ReversePlatformType      xray_structs.ReverseType
 	DnslogCNGetDomainRequest *http.Request
 	DnslogCNGetRecordRequest *http.Request
+	// dnslog.cn通过cookie中的session关联域名和记录
+	DnslogCNCookieJar http.CookieJar
 )
 
 func InitReversePlatform(api, domain string, timeout time.Duration) {
@@ -27,6 +30,7 @@ func InitReversePlatform(api, domain string, timeout time.Duration) {
 		// 设置请求相关参数
 		DnslogCNGetDomainRequest, _ = http.NewRequest("GET", "http://dnslog.cn/getdomain.php", nil)
 		DnslogCNGetRecordRequest, _ = http.NewRequest("GET", "http://dnslog.cn/getrecords.php", nil)
+		DnslogCNCookieJar, _ = cookiejar.New(nil)
 
 	}
 }
@@ -1,12 +1,14 @@
 package parse
 
 import (
+	"net/url"
+	"strings"
+
 	"github.com/WAY29/errors"
 	"github.com/WAY29/pocV/pkg/nuclei/structs"
 	"github.com/WAY29/pocV/utils"
 	"github.com/projectdiscovery/nuclei/v2/pkg/catalog"
 	"github.com/projectdiscovery/nuclei/v2/pkg/protocols"
-	"github.com/projectdiscovery/nuclei/v2/pkg/protocols/common/protocolinit"
 	"github.com/projectdiscovery/nuclei/v2/pkg/templates"
 	"github.com/projectdiscovery/nuclei/v2/pkg/types"
 
@@ -17,32 +19,80 @@ var (
 	ExecuterOptions protocols.ExecuterOptions
 )
 
-func InitExecuterOptions(rate int, timeout int) {
+func InitExecuterOptions(options structs.Options) {
 	fakeWriter := structs.FakeWrite{}
-	progress := &structs.FakeProgress{}
+
+	progressClient := options.Progress
+	if progressClient == nil {
+		progressClient = &structs.FakeProgress{}
+	}
+
+	// 自定义请求头
+	customHeaders := make([]string, 0, len(options.Headers))
+	for k := range options.Headers {
+		customHeaders = append(customHeaders, k+": "+options.Headers.Get(k))
+	}
+
 	o := types.Options{
-		RateLimit:               rate,
+		RateLimit:               options.Rate,
 		BulkSize:                25,
 		TemplateThreads:         25,
 		HeadlessBulkSize:        10,
 		HeadlessTemplateThreads: 10,
-		Timeout:                 timeout,
+		Timeout:                 options.Timeout,
 		Retries:                 1,
 		MaxHostError:            30,
+		CustomHeaders:           customHeaders,
+		Proxy:                   options.Proxies,
 	}
-	err := protocolinit.Init(&o)
+
+	// nuclei只支持一个全局代理，使用第一个代理
+	types.ProxyURL, types.ProxySocksURL = "", ""
+	if len(options.Proxies) > 1 {
+		utils.WarningF("Nuclei doesn't support proxy rotation, only use the first proxy: %s", options.Proxies[0])
+	}
+	if len(options.Proxies) > 0 {
+		proxyURL, err := url.Parse(options.Proxies[0])
+		if err != nil || proxyURL.Host == "" {
+			utils.CliError("Nuclei parse proxy error: "+options.Proxies[0], 2)
+			return
+		}
+		if strings.HasPrefix(proxyURL.Scheme, "socks5") {
+			types.ProxySocksURL = proxyURL.String()
+		} else {
+			types.ProxyURL = proxyURL.String()
+		}
+	}
+
+	// 客户端证书，nuclei要求同时指定CA证书
+	if options.ClientCert != "" {
+		o.ClientCertFile = options.ClientCert
+		o.ClientKeyFile = options.ClientKey
+		o.ClientCAFile = options.ClientCA
+		if o.ClientKeyFile == "" {
+			o.ClientKeyFile = o.ClientCertFile
+		}
+		if o.ClientCAFile == "" {
+			// nuclei要求CA证书可读，由于不校验服务端证书，CA证书实际不会被使用
+			utils.WarningF("Nuclei requires --client-ca with --client-cert, use client certificate as CA certificate")
+			o.ClientCAFile = o.ClientCertFile
+		}
+	}
+
+	err := initProtocols(&o, options.Resolvers, options.Hosts)
 	if err != nil {
-		utils.CliError("Nuclei InitExecuterOptions error", 2)
+		utils.CliError("Nuclei InitExecuterOptions error: "+err.Error(), 2)
 		return
 	}
+	configureTLS(&o, options.TLSConfig)
 
 	catalog2 := catalog.New("")
 	ExecuterOptions = protocols.ExecuterOptions{
 		Output:      &fakeWriter,
 		Options:     &o,
-		Progress:    progress,
+		Progress:    progressClient,
 		Catalog:     catalog2,
-		RateLimiter: ratelimit.New(rate),
+		RateLimiter: ratelimit.New(options.Rate),
 	}
 
 }
@@ -59,5 +109,6 @@ func ParsePoc(filename string) (*structs.Poc, error) {
 	if poc.ID == "" {
 		return nil, errors.New("Nuclei poc id can't be nil")
 	}
+	configureTemplateTLS(poc)
 	return poc, nil
 }
@@ -0,0 +1,76 @@
+package parse
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/projectdiscovery/fastdialer/fastdialer"
+	"github.com/projectdiscovery/nuclei/v2/pkg/protocols/common/protocolinit"
+	"github.com/projectdiscovery/nuclei/v2/pkg/types"
+)
+
+// 初始化nuclei协议，并传入自定义dns服务器和静态映射
+//
+// nuclei没有提供对应的配置项，这里依赖以下内部实现，升级nuclei或fastdialer时需要重新确认:
+//   - nuclei v2.6.0 pkg/protocols/common/protocolstate.Init 只在options.ResolversFile不为空时
+//     才将options.InternalResolversList作为fastdialer的BaseResolvers，因此ResolversFile只需非空，不会被读取
+//   - fastdialer v0.0.15 fastdialer/hostsfile.go loadHostsFile 在NewDialer时读取环境变量HOSTS_PATH
+//     指定的hosts文件，因此在protocolinit.Init期间临时设置HOSTS_PATH，结束后恢复
+func initProtocols(o *types.Options, resolvers []string, hosts map[string]string) error {
+	if len(resolvers) > 0 {
+		o.ResolversFile = "--resolvers"
+		o.InternalResolversList = resolvers
+	}
+
+	if len(hosts) > 0 {
+		hostsFile, err := writeHostsFile(hosts)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(hostsFile)
+
+		oldHostsPath, isset := os.LookupEnv("HOSTS_PATH")
+		os.Setenv("HOSTS_PATH", hostsFile)
+		defer func() {
+			if isset {
+				os.Setenv("HOSTS_PATH", oldHostsPath)
+			} else {
+				os.Unsetenv("HOSTS_PATH")
+			}
+		}()
+	}
+
+	return protocolinit.Init(o)
+}
+
+// 生成包含静态映射和系统hosts的临时文件，静态映射优先
+func writeHostsFile(hosts map[string]string) (string, error) {
+	var builder strings.Builder
+
+	names := make([]string, 0, len(hosts))
+	for host := range hosts {
+		names = append(names, host)
+	}
+	sort.Strings(names)
+	for _, host := range names {
+		builder.WriteString(hosts[host] + " " + host + "\n")
+	}
+	if systemHosts, err := ioutil.ReadFile(os.ExpandEnv(filepath.FromSlash(fastdialer.HostsFilePath))); err == nil {
+		builder.Write(systemHosts)
+	}
+
+	f, err := ioutil.TempFile("", "pocV-hosts-")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err = f.WriteString(builder.String()); err != nil {
+		return "", err
+	}
+
+	return f.Name(), nil
+}
@@ -0,0 +1,77 @@
+package parse
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/WAY29/pocV/internal/common/testutils"
+	"github.com/WAY29/pocV/utils"
+	"github.com/projectdiscovery/nuclei/v2/pkg/protocols/common/protocolstate"
+	"github.com/projectdiscovery/nuclei/v2/pkg/types"
+)
+
+func TestMain(m *testing.M) {
+	utils.InitLog(false, false)
+	os.Exit(m.Run())
+}
+
+func TestWriteHostsFile(t *testing.T) {
+	hostsFile, err := writeHostsFile(map[string]string{
+		"b.pocv.test": "10.0.0.2",
+		"a.pocv.test": "10.0.0.1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(hostsFile)
+
+	content, err := ioutil.ReadFile(hostsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 静态映射按域名排序，位于系统hosts之前
+	if !strings.HasPrefix(string(content), "10.0.0.1 a.pocv.test\n10.0.0.2 b.pocv.test\n") {
+		t.Errorf("unexpected hosts file content: %q", content)
+	}
+	if systemHosts, err := ioutil.ReadFile("/etc/hosts"); err == nil && !strings.HasSuffix(string(content), string(systemHosts)) {
+		t.Errorf("hosts file should contain system hosts, got %q", content)
+	}
+}
+
+func TestInitProtocols(t *testing.T) {
+	nameserver, queries := testutils.NewDNSServer(t, "10.0.0.9")
+	os.Setenv("HOSTS_PATH", "/nonexistent/hosts")
+	defer os.Unsetenv("HOSTS_PATH")
+
+	o := types.Options{Timeout: 5, Retries: 1, MaxHostError: 30}
+	if err := initProtocols(&o, []string{nameserver}, map[string]string{"static.pocv.test": "10.0.0.8"}); err != nil {
+		t.Fatal(err)
+	}
+	defer protocolstate.Close()
+
+	// HOSTS_PATH只在初始化期间修改
+	if path := os.Getenv("HOSTS_PATH"); path != "/nonexistent/hosts" {
+		t.Errorf("HOSTS_PATH should be restored, got %s", path)
+	}
+
+	// 依赖fastdialer从HOSTS_PATH加载静态映射
+	data, err := protocolstate.Dialer.GetDNSData("static.pocv.test")
+	if err != nil || len(data.A) == 0 || data.A[0] != "10.0.0.8" {
+		t.Errorf("static resolve got %+v, %v", data, err)
+	}
+	if atomic.LoadInt64(queries) != 0 {
+		t.Errorf("static host should not be queried, got %d queries", atomic.LoadInt64(queries))
+	}
+
+	// 依赖protocolstate在ResolversFile不为空时使用InternalResolversList
+	data, err = protocolstate.Dialer.GetDNSData("dynamic.pocv.test")
+	if err != nil || len(data.A) == 0 || data.A[0] != "10.0.0.9" {
+		t.Errorf("custom resolver got %+v, %v", data, err)
+	}
+	if atomic.LoadInt64(queries) == 0 {
+		t.Error("custom resolver was not queried")
+	}
+}
@@ -0,0 +1,89 @@
+package parse
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/WAY29/pocV/pkg/nuclei/structs"
+	"github.com/WAY29/pocV/utils"
+	nuclei_http "github.com/projectdiscovery/nuclei/v2/pkg/protocols/http"
+	"github.com/projectdiscovery/nuclei/v2/pkg/protocols/http/httpclientpool"
+	"github.com/projectdiscovery/nuclei/v2/pkg/types"
+	"github.com/projectdiscovery/retryablehttp-go"
+)
+
+var (
+	// 自定义的tls版本、加密套件和SNI
+	tlsConfig *tls.Config
+)
+
+// nuclei不支持配置tls版本和加密套件，修改默认http客户端的tls配置
+func configureTLS(o *types.Options, config *tls.Config) {
+	tlsConfig = config
+	if !tlsCustomized() {
+		return
+	}
+
+	client, err := httpclientpool.Get(o, &httpclientpool.Configuration{})
+	if err != nil {
+		utils.WarningF("Nuclei get http client error: %v", err)
+		return
+	}
+	applyTLSConfig(client)
+}
+
+// 修改模板使用的http客户端的tls配置
+//
+// 模板编译时按请求的配置从httpclientpool获取客户端，相同配置的客户端会被复用，
+// 因此用相同的配置再次获取即可得到模板使用的客户端。开启cookie-reuse的客户端不会被复用，
+// unsafe请求使用rawhttp，这两种情况无法修改，只输出警告
+func configureTemplateTLS(poc *structs.Poc) {
+	if !tlsCustomized() || ExecuterOptions.Options == nil {
+		return
+	}
+
+	for _, request := range poc.RequestsHTTP {
+		if request.CookieReuse || request.Unsafe {
+			utils.WarningF("Nuclei poc[%s] uses cookie-reuse or unsafe request, --tls-min, --tls-max, --ciphers and --sni are not applied", poc.ID)
+			continue
+		}
+
+		client, err := httpclientpool.Get(ExecuterOptions.Options, requestConfiguration(request))
+		if err != nil {
+			utils.WarningF("Nuclei poc[%s] get http client error: %v", poc.ID, err)
+			continue
+		}
+		applyTLSConfig(client)
+	}
+}
+
+// 与nuclei v2.6.0 pkg/protocols/http.Request.Compile 中的配置保持一致
+func requestConfiguration(request *nuclei_http.Request) *httpclientpool.Configuration {
+	configuration := &httpclientpool.Configuration{
+		Threads:         request.Threads,
+		MaxRedirects:    request.MaxRedirects,
+		FollowRedirects: request.Redirects,
+		CookieReuse:     request.CookieReuse,
+	}
+	if _, hasConnectionHeader := request.Headers["Connection"]; hasConnectionHeader {
+		configuration.Connection = &httpclientpool.ConnectionConfiguration{DisableKeepAlive: false}
+	}
+	return configuration
+}
+
+func applyTLSConfig(client *retryablehttp.Client) {
+	transport, ok := client.HTTPClient.Transport.(*http.Transport)
+	if !ok || transport.TLSClientConfig == nil {
+		return
+	}
+
+	config := transport.TLSClientConfig
+	config.MinVersion = tlsConfig.MinVersion
+	config.MaxVersion = tlsConfig.MaxVersion
+	config.CipherSuites = tlsConfig.CipherSuites
+	config.ServerName = tlsConfig.ServerName
+}
+
+func tlsCustomized() bool {
+	return tlsConfig != nil && (tlsConfig.MinVersion != 0 || tlsConfig.MaxVersion != 0 || len(tlsConfig.CipherSuites) > 0 || tlsConfig.ServerName != "")
+}
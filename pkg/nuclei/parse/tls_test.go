@@ -0,0 +1,86 @@
+package parse
+
+import (
+	"crypto/tls"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/WAY29/pocV/pkg/nuclei/structs"
+	"github.com/projectdiscovery/nuclei/v2/pkg/protocols/common/protocolstate"
+	nuclei_http "github.com/projectdiscovery/nuclei/v2/pkg/protocols/http"
+	"github.com/projectdiscovery/nuclei/v2/pkg/protocols/http/httpclientpool"
+	"github.com/projectdiscovery/nuclei/v2/pkg/types"
+)
+
+func clientTLSConfig(t *testing.T, o *types.Options, configuration *httpclientpool.Configuration) *tls.Config {
+	client, err := httpclientpool.Get(o, configuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.HTTPClient.Transport.(*http.Transport).TLSClientConfig
+}
+
+func TestConfigureTemplateTLS(t *testing.T) {
+	o := types.Options{Timeout: 5, Retries: 1, MaxHostError: 30}
+	if err := initProtocols(&o, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer protocolstate.Close()
+
+	oldOptions := ExecuterOptions.Options
+	ExecuterOptions.Options = &o
+	defer func() {
+		ExecuterOptions.Options = oldOptions
+		tlsConfig = nil
+	}()
+
+	// 未自定义tls配置时不修改客户端
+	configureTLS(&o, &tls.Config{InsecureSkipVerify: true})
+	untouched := &nuclei_http.Request{Threads: 7}
+	configureTemplateTLS(&structs.Poc{ID: "untouched", RequestsHTTP: []*nuclei_http.Request{untouched}})
+	if config := clientTLSConfig(t, &o, requestConfiguration(untouched)); config.MinVersion != 0 || config.ServerName != "" {
+		t.Errorf("tls config should not be modified without custom options: %+v", config)
+	}
+
+	custom := &tls.Config{
+		MinVersion:   tls.VersionTLS11,
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_RSA_WITH_AES_128_CBC_SHA},
+		ServerName:   "sni.pocv.test",
+	}
+	check := func(name string, config *tls.Config) {
+		if config.MinVersion != custom.MinVersion || config.MaxVersion != custom.MaxVersion ||
+			len(config.CipherSuites) != 1 || config.ServerName != custom.ServerName || !config.InsecureSkipVerify {
+			t.Errorf("%s: tls config was not applied: %+v", name, config)
+		}
+	}
+
+	// 默认客户端
+	configureTLS(&o, custom)
+	check("default client", clientTLSConfig(t, &o, &httpclientpool.Configuration{}))
+
+	// 模板编译时获取的客户端
+	redirects := &nuclei_http.Request{Redirects: true, MaxRedirects: 3}
+	threads := &nuclei_http.Request{Threads: 10, Headers: map[string]string{"Connection": "close"}}
+	cookieReuse := &nuclei_http.Request{CookieReuse: true}
+	configureTemplateTLS(&structs.Poc{ID: "custom", RequestsHTTP: []*nuclei_http.Request{redirects, threads, cookieReuse}})
+	check("redirects client", clientTLSConfig(t, &o, requestConfiguration(redirects)))
+	check("threads client", clientTLSConfig(t, &o, requestConfiguration(threads)))
+
+	// 与nuclei编译模板时获取到的客户端一致
+	request := &nuclei_http.Request{Redirects: true, MaxRedirects: 5, Path: []string{"{{BaseURL}}"}, Method: nuclei_http.HTTPMethodTypeHolder{MethodType: nuclei_http.HTTPGet}}
+	executerOptions := ExecuterOptions
+	if err := request.Compile(&executerOptions); err != nil {
+		t.Fatal(err)
+	}
+	client, err := httpclientpool.Get(&o, requestConfiguration(request))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compiled := reflect.ValueOf(request).Elem().FieldByName("httpClient").Pointer(); compiled != reflect.ValueOf(client).Pointer() {
+		t.Fatal("client from httpclientpool is not the client used by compiled request")
+	}
+	configureTemplateTLS(&structs.Poc{ID: "compiled", RequestsHTTP: []*nuclei_http.Request{request}})
+	check("compiled client", client.HTTPClient.Transport.(*http.Transport).TLSClientConfig)
+}
@@ -0,0 +1,29 @@
+package structs
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/projectdiscovery/nuclei/v2/pkg/progress"
+)
+
+// nuclei执行选项
+type Options struct {
+	Rate     int
+	Timeout  int
+	Progress progress.Progress
+	// 自定义请求头
+	Headers http.Header
+	// 代理，nuclei只使用第一个
+	Proxies []string
+	// host:ip静态映射
+	Hosts map[string]string
+	// 自定义dns服务器，ip:port形式
+	Resolvers []string
+	// 客户端证书，CA证书为空时使用客户端证书
+	ClientCert string
+	ClientKey  string
+	ClientCA   string
+	// tls版本、加密套件和SNI，作用于nuclei的http客户端，cookie-reuse和unsafe请求除外
+	TLSConfig *tls.Config
+}
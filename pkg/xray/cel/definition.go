@@ -22,6 +22,8 @@ var (
 			&structs.Request{},
 			&structs.Response{},
 			&structs.Reverse{},
+			&structs.TlsInfoType{},
+			&structs.CertificateType{},
 			StrStrMapType,
 		),
 		cel.Declarations(
@@ -137,6 +139,8 @@ var (
 					[]*exprpb.Type{decls.String, decls.String},
 					decls.String)),
 		),
+		cel.Declarations(EncodingDeclarations...),
+		cel.Declarations(VersionDeclarations...),
 	}
 )
 
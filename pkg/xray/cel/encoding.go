@@ -0,0 +1,244 @@
+package cel
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"strings"
+
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/interpreter/functions"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// 哈希、hmac和编码函数，均支持string和bytes参数
+var (
+	EncodingDeclarations = []*exprpb.Decl{
+		decls.NewFunction("md5",
+			decls.NewOverload("md5_bytes",
+				[]*exprpb.Type{decls.Bytes},
+				decls.String)),
+		decls.NewFunction("sha1",
+			decls.NewOverload("sha1_string",
+				[]*exprpb.Type{decls.String},
+				decls.String),
+			decls.NewOverload("sha1_bytes",
+				[]*exprpb.Type{decls.Bytes},
+				decls.String)),
+		decls.NewFunction("sha256",
+			decls.NewOverload("sha256_string",
+				[]*exprpb.Type{decls.String},
+				decls.String),
+			decls.NewOverload("sha256_bytes",
+				[]*exprpb.Type{decls.Bytes},
+				decls.String)),
+		decls.NewFunction("sha512",
+			decls.NewOverload("sha512_string",
+				[]*exprpb.Type{decls.String},
+				decls.String),
+			decls.NewOverload("sha512_bytes",
+				[]*exprpb.Type{decls.Bytes},
+				decls.String)),
+		// hmacXxx(data, key)
+		decls.NewFunction("hmacMd5",
+			decls.NewOverload("hmacMd5_string_string",
+				[]*exprpb.Type{decls.String, decls.String},
+				decls.String),
+			decls.NewOverload("hmacMd5_bytes_bytes",
+				[]*exprpb.Type{decls.Bytes, decls.Bytes},
+				decls.String)),
+		decls.NewFunction("hmacSha1",
+			decls.NewOverload("hmacSha1_string_string",
+				[]*exprpb.Type{decls.String, decls.String},
+				decls.String),
+			decls.NewOverload("hmacSha1_bytes_bytes",
+				[]*exprpb.Type{decls.Bytes, decls.Bytes},
+				decls.String)),
+		decls.NewFunction("hmacSha256",
+			decls.NewOverload("hmacSha256_string_string",
+				[]*exprpb.Type{decls.String, decls.String},
+				decls.String),
+			decls.NewOverload("hmacSha256_bytes_bytes",
+				[]*exprpb.Type{decls.Bytes, decls.Bytes},
+				decls.String)),
+		decls.NewFunction("hexencode",
+			decls.NewOverload("hexencode_string",
+				[]*exprpb.Type{decls.String},
+				decls.String),
+			decls.NewOverload("hexencode_bytes",
+				[]*exprpb.Type{decls.Bytes},
+				decls.String)),
+		decls.NewFunction("hexdecode",
+			decls.NewOverload("hexdecode_string",
+				[]*exprpb.Type{decls.String},
+				decls.String),
+			decls.NewOverload("hexdecode_bytes",
+				[]*exprpb.Type{decls.Bytes},
+				decls.String)),
+		decls.NewFunction("rot13",
+			decls.NewOverload("rot13_string",
+				[]*exprpb.Type{decls.String},
+				decls.String),
+			decls.NewOverload("rot13_bytes",
+				[]*exprpb.Type{decls.Bytes},
+				decls.Bytes)),
+		decls.NewFunction("upper",
+			decls.NewOverload("upper_string",
+				[]*exprpb.Type{decls.String},
+				decls.String),
+			decls.NewOverload("upper_bytes",
+				[]*exprpb.Type{decls.Bytes},
+				decls.Bytes)),
+		decls.NewFunction("lower",
+			decls.NewOverload("lower_string",
+				[]*exprpb.Type{decls.String},
+				decls.String),
+			decls.NewOverload("lower_bytes",
+				[]*exprpb.Type{decls.Bytes},
+				decls.Bytes)),
+	}
+
+	EncodingOverloads = concatOverloads(
+		[]*functions.Overload{
+			unaryBytesOverload("md5_bytes", "md5", hashHex(md5.New)),
+		},
+		unaryOverloads("sha1", hashHex(sha1.New)),
+		unaryOverloads("sha256", hashHex(sha256.New)),
+		unaryOverloads("sha512", hashHex(sha512.New)),
+		hmacOverloads("hmacMd5", md5.New),
+		hmacOverloads("hmacSha1", sha1.New),
+		hmacOverloads("hmacSha256", sha256.New),
+		unaryOverloads("hexencode", func(v []byte) ref.Val {
+			return types.String(hex.EncodeToString(v))
+		}),
+		unaryOverloads("hexdecode", func(v []byte) ref.Val {
+			decodeBytes, err := hex.DecodeString(strings.TrimSpace(string(v)))
+			if err != nil {
+				return types.NewErr("%v", err)
+			}
+			return types.String(decodeBytes)
+		}),
+		[]*functions.Overload{
+			unaryStringOverload("rot13_string", "rot13", func(v []byte) ref.Val {
+				return types.String(rot13(v))
+			}),
+			unaryBytesOverload("rot13_bytes", "rot13", func(v []byte) ref.Val {
+				return types.Bytes(rot13(v))
+			}),
+			unaryStringOverload("upper_string", "upper", func(v []byte) ref.Val {
+				return types.String(strings.ToUpper(string(v)))
+			}),
+			unaryBytesOverload("upper_bytes", "upper", func(v []byte) ref.Val {
+				return types.Bytes(strings.ToUpper(string(v)))
+			}),
+			unaryStringOverload("lower_string", "lower", func(v []byte) ref.Val {
+				return types.String(strings.ToLower(string(v)))
+			}),
+			unaryBytesOverload("lower_bytes", "lower", func(v []byte) ref.Val {
+				return types.Bytes(strings.ToLower(string(v)))
+			}),
+		},
+	)
+)
+
+func concatOverloads(overloads ...[]*functions.Overload) []*functions.Overload {
+	result := make([]*functions.Overload, 0)
+	for _, o := range overloads {
+		result = append(result, o...)
+	}
+	return result
+}
+
+// 将参数转换为[]byte，支持string和bytes
+func toBytes(value ref.Val) ([]byte, bool) {
+	switch v := value.(type) {
+	case types.String:
+		return []byte(v), true
+	case types.Bytes:
+		return []byte(v), true
+	}
+	return nil, false
+}
+
+func unaryStringOverload(operator, name string, fn func([]byte) ref.Val) *functions.Overload {
+	return &functions.Overload{
+		Operator: operator,
+		Unary: func(value ref.Val) ref.Val {
+			v, ok := value.(types.String)
+			if !ok {
+				return types.ValOrErr(value, "unexpected type '%v' passed to %s", value.Type(), name)
+			}
+			return fn([]byte(v))
+		},
+	}
+}
+
+func unaryBytesOverload(operator, name string, fn func([]byte) ref.Val) *functions.Overload {
+	return &functions.Overload{
+		Operator: operator,
+		Unary: func(value ref.Val) ref.Val {
+			v, ok := value.(types.Bytes)
+			if !ok {
+				return types.ValOrErr(value, "unexpected type '%v' passed to %s", value.Type(), name)
+			}
+			return fn([]byte(v))
+		},
+	}
+}
+
+// 生成name_string和name_bytes两个重载
+func unaryOverloads(name string, fn func([]byte) ref.Val) []*functions.Overload {
+	return []*functions.Overload{
+		unaryStringOverload(name+"_string", name, fn),
+		unaryBytesOverload(name+"_bytes", name, fn),
+	}
+}
+
+func hmacOverloads(name string, h func() hash.Hash) []*functions.Overload {
+	binary := func(lhs ref.Val, rhs ref.Val) ref.Val {
+		data, ok := toBytes(lhs)
+		if !ok {
+			return types.ValOrErr(lhs, "unexpected type '%v' passed to %s", lhs.Type(), name)
+		}
+		key, ok := toBytes(rhs)
+		if !ok {
+			return types.ValOrErr(rhs, "unexpected type '%v' passed to %s", rhs.Type(), name)
+		}
+		mac := hmac.New(h, key)
+		mac.Write(data)
+		return types.String(hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	return []*functions.Overload{
+		{Operator: name + "_string_string", Binary: binary},
+		{Operator: name + "_bytes_bytes", Binary: binary},
+	}
+}
+
+func hashHex(h func() hash.Hash) func([]byte) ref.Val {
+	return func(v []byte) ref.Val {
+		hasher := h()
+		hasher.Write(v)
+		return types.String(hex.EncodeToString(hasher.Sum(nil)))
+	}
+}
+
+func rot13(v []byte) []byte {
+	result := make([]byte, len(v))
+	for i, c := range v {
+		switch {
+		case c >= 'a' && c <= 'z':
+			c = 'a' + (c-'a'+13)%26
+		case c >= 'A' && c <= 'Z':
+			c = 'A' + (c-'A'+13)%26
+		}
+		result[i] = c
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+package cel
+
+import (
+	"os"
+	"testing"
+
+	"github.com/WAY29/pocV/utils"
+)
+
+func TestMain(m *testing.M) {
+	utils.InitLog(false, false)
+	os.Exit(m.Run())
+}
+
+func evaluate(t *testing.T, expression string) interface{} {
+	t.Helper()
+
+	c := NewEnvOption()
+	defer PutCustomLib(c)
+	env, err := NewEnv(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := Evaluate(env, expression, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("evaluate %s error: %v", expression, err)
+	}
+	return out.Value()
+}
+
+func TestEncodingFunctions(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       interface{}
+	}{
+		{"md5_string", `md5("pocV")`, "fcc01e59ff84afcc754ec38d5fcf067f"},
+		{"md5_bytes", `md5(b"pocV")`, "fcc01e59ff84afcc754ec38d5fcf067f"},
+		{"sha1_string", `sha1("abc")`, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"sha1_bytes", `sha1(b"abc")`, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"sha256_string", `sha256("abc")`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"sha256_bytes", `sha256(b"abc")`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"sha512_string", `sha512("abc")`, "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"},
+		{"sha512_bytes", `sha512(b"abc")`, "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"},
+		{"hmacMd5_string_string", `hmacMd5("The quick brown fox jumps over the lazy dog", "key")`, "80070713463e7749b90c2dc24911e275"},
+		{"hmacMd5_bytes_bytes", `hmacMd5(b"The quick brown fox jumps over the lazy dog", b"key")`, "80070713463e7749b90c2dc24911e275"},
+		{"hmacSha1_string_string", `hmacSha1("The quick brown fox jumps over the lazy dog", "key")`, "de7c9b85b8b78aa6bc8a7a36f70a90701c9db4d9"},
+		{"hmacSha1_bytes_bytes", `hmacSha1(b"The quick brown fox jumps over the lazy dog", b"key")`, "de7c9b85b8b78aa6bc8a7a36f70a90701c9db4d9"},
+		{"hmacSha256_string_string", `hmacSha256("The quick brown fox jumps over the lazy dog", "key")`, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{"hmacSha256_bytes_bytes", `hmacSha256(b"The quick brown fox jumps over the lazy dog", b"key")`, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{"hexencode_string", `hexencode("pocV")`, "706f6356"},
+		{"hexencode_bytes", `hexencode(b"\x00\xff")`, "00ff"},
+		{"hexdecode_string", `hexdecode("706f6356")`, "pocV"},
+		{"hexdecode_bytes", `hexdecode(b"706F6356")`, "pocV"},
+		{"rot13_string", `rot13("Hello, World!")`, "Uryyb, Jbeyq!"},
+		{"rot13_bytes", `rot13(b"Uryyb")`, []byte("Hello")},
+		{"upper_string", `upper("pocV")`, "POCV"},
+		{"upper_bytes", `upper(b"pocV")`, []byte("POCV")},
+		{"lower_string", `lower("PocV")`, "pocv"},
+		{"lower_bytes", `lower(b"PocV")`, []byte("pocv")},
+		{"bytes_string", `bytes("pocV") == b"pocV"`, true},
+		{"string_bytes", `string(b"pocV") == "pocV"`, true},
+		{"base64_string", `base64("pocV")`, "cG9jVg=="},
+		{"base64_bytes", `base64(b"pocV")`, "cG9jVg=="},
+		{"base64Decode_string", `base64Decode("cG9jVg==")`, "pocV"},
+		{"base64Decode_bytes", `base64Decode(b"cG9jVg==")`, "pocV"},
+		{"urlencode_string", `urlencode("a b&c")`, "a+b%26c"},
+		{"urlencode_bytes", `urlencode(b"a b&c")`, "a+b%26c"},
+		{"urldecode_string", `urldecode("a+b%26c")`, "a b&c"},
+		{"urldecode_bytes", `urldecode(b"a+b%26c")`, "a b&c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluate(t, tt.expression)
+			switch want := tt.want.(type) {
+			case []byte:
+				if string(got.([]byte)) != string(want) {
+					t.Errorf("%s = %q, want %q", tt.expression, got, want)
+				}
+			default:
+				if got != want {
+					t.Errorf("%s = %v, want %v", tt.expression, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodingFunctionErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{"hexdecode_invalid", `hexdecode("zz")`},
+		{"hexdecode_odd_length", `hexdecode(b"abc")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewEnvOption()
+			defer PutCustomLib(c)
+			env, err := NewEnv(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Evaluate(env, tt.expression, map[string]interface{}{}); err == nil {
+				t.Errorf("%s should return error", tt.expression)
+			}
+		})
+	}
+}
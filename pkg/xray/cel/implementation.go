@@ -15,6 +15,7 @@ import (
 	"unicode"
 
 	"github.com/WAY29/pocV/internal/common/errors"
+	"github.com/WAY29/pocV/internal/common/metrics"
 	common_structs "github.com/WAY29/pocV/pkg/common/structs"
 	"github.com/WAY29/pocV/pkg/xray/requests"
 	"github.com/WAY29/pocV/pkg/xray/structs"
@@ -106,7 +107,10 @@ var (
 					if !ok {
 						return types.ValOrErr(rhs, "unexpected type '%v' passed to bmatches", rhs.Type())
 					}
-					re := regexp2.MustCompile(string(v1), 0)
+					re, err := CompileRegexp(string(v1), 0)
+					if err != nil {
+						return types.NewErr("invalid regexp '%s' passed to bmatches: %v", v1, err)
+					}
 					if isMatch, err = re.MatchString(string([]byte(v2))); err != nil {
 						return types.NewErr("%v", err)
 					}
@@ -130,7 +134,10 @@ var (
 						return types.ValOrErr(rhs, "unexpected type '%v' passed to matches", rhs.Type())
 					}
 
-					re := regexp2.MustCompile(string(v1), 0)
+					re, err := CompileRegexp(string(v1), 0)
+					if err != nil {
+						return types.NewErr("invalid regexp '%s' passed to matches: %v", v1, err)
+					}
 					if isMatch, err = re.MatchString(string(v2)); err != nil {
 						return types.NewErr("%v", err)
 					}
@@ -396,6 +403,8 @@ var (
 				},
 			},
 		),
+		cel.Functions(EncodingOverloads...),
+		cel.Functions(VersionOverloads...),
 	}
 )
 
@@ -405,10 +414,6 @@ func NewFunctionImplOptions(reg ref.TypeRegistry) []cel.ProgramOption {
 			&functions.Overload{
 				Operator: "string_submatch_string",
 				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
-					var (
-						resultMap = make(map[string]string)
-					)
-
 					v1, ok := lhs.(types.String)
 					if !ok {
 						return types.ValOrErr(lhs, "unexpected type '%v' passed to submatch", lhs.Type())
@@ -418,15 +423,9 @@ func NewFunctionImplOptions(reg ref.TypeRegistry) []cel.ProgramOption {
 						return types.ValOrErr(rhs, "unexpected type '%v' passed to submatch", rhs.Type())
 					}
 
-					re := regexp2.MustCompile(string(v1), regexp2.RE2)
-					if m, _ := re.FindStringMatch(string(v2)); m != nil {
-						gps := m.Groups()
-						for n, gp := range gps {
-							if n == 0 {
-								continue
-							}
-							resultMap[gp.Name] = gp.String()
-						}
+					resultMap, err := regexpSubmatch(string(v1), regexp2.RE2, string(v2))
+					if err != nil {
+						return types.NewErr("submatch '%s' error: %v", v1, err)
 					}
 					return types.NewStringStringMap(reg, resultMap)
 				},
@@ -434,10 +433,6 @@ func NewFunctionImplOptions(reg ref.TypeRegistry) []cel.ProgramOption {
 			&functions.Overload{
 				Operator: "string_bsubmatch_bytes",
 				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
-					var (
-						resultMap = make(map[string]string)
-					)
-
 					v1, ok := lhs.(types.String)
 					if !ok {
 						return types.ValOrErr(lhs, "unexpected type '%v' passed to bsubmatch", lhs.Type())
@@ -447,17 +442,10 @@ func NewFunctionImplOptions(reg ref.TypeRegistry) []cel.ProgramOption {
 						return types.ValOrErr(rhs, "unexpected type '%v' passed to bsubmatch", rhs.Type())
 					}
 
-					re := regexp2.MustCompile(string(v1), regexp2.RE2)
-					if m, _ := re.FindStringMatch(string([]byte(v2))); m != nil {
-						gps := m.Groups()
-						for n, gp := range gps {
-							if n == 0 {
-								continue
-							}
-							resultMap[gp.Name] = gp.String()
-						}
+					resultMap, err := regexpSubmatch(string(v1), regexp2.RE2, string([]byte(v2)))
+					if err != nil {
+						return types.NewErr("bsubmatch '%s' error: %v", v1, err)
 					}
-
 					return types.NewStringStringMap(reg, resultMap)
 				},
 			},
@@ -490,7 +478,7 @@ func xrayNewReverse() (reverse *structs.Reverse) {
 		urlStr = fmt.Sprintf("http://%s.%s/", sub, common_structs.CeyeDomain)
 	case structs.ReverseType_DnslogCN:
 		dnslogCnRequest := common_structs.DnslogCNGetDomainRequest
-		resp, _, err := requests.DoRequest(dnslogCnRequest, false)
+		resp, _, err := requests.DoRequest(dnslogCnRequest, false, common_structs.DnslogCNCookieJar)
 		if err != nil {
 			wrappedErr := errors.Wrap(err, "Get reverse domain error: Can't get domain from dnslog.cn")
 			utils.ErrorP(wrappedErr)
@@ -518,12 +506,14 @@ func reverseCheck(r *structs.Reverse, timeout int64) bool {
 		return false
 	}
 
+	metrics.IncrementReversePolls(r.ReverseType.String())
+
 	switch r.ReverseType {
 	case structs.ReverseType_Ceye:
 		time.Sleep(time.Second * time.Duration(timeout))
 		urlStr := fmt.Sprintf("http://api.ceye.io/v1/records?token=%s&type=dns&filter=%s", common_structs.CeyeApi, sub)
 		req, _ := http.NewRequest("GET", urlStr, nil)
-		resp, _, err := requests.DoRequest(req, false)
+		resp, _, err := requests.DoRequest(req, false, nil)
 		if err != nil {
 			wrappedErr := errors.Wrap(err, "Reverse check error")
 			utils.ErrorP(wrappedErr)
@@ -538,7 +528,7 @@ func reverseCheck(r *structs.Reverse, timeout int64) bool {
 		return false
 	case structs.ReverseType_DnslogCN:
 		time.Sleep(time.Second * time.Duration(timeout))
-		resp, _, err := requests.DoRequest(common_structs.DnslogCNGetRecordRequest, false)
+		resp, _, err := requests.DoRequest(common_structs.DnslogCNGetRecordRequest, false, common_structs.DnslogCNCookieJar)
 		if err != nil {
 			wrappedErr := errors.Wrap(err, "Reverse check error")
 			utils.ErrorP(wrappedErr)
@@ -0,0 +1,63 @@
+package cel
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bluele/gcache"
+	"github.com/dlclark/regexp2"
+)
+
+const DefaultRegexpCacheSize = 1024
+
+var (
+	// 单次正则匹配的超时时间，防止灾难性回溯导致线程卡死
+	RegexpMatchTimeout = 3 * time.Second
+
+	regexpCache = gcache.New(DefaultRegexpCacheSize).LRU().Build()
+)
+
+// 编译正则并按pattern和options缓存
+func CompileRegexp(pattern string, options regexp2.RegexOptions) (*regexp2.Regexp, error) {
+	key := fmt.Sprintf("%d:%s", options, pattern)
+	if cache, err := regexpCache.Get(key); err == nil {
+		if re, ok := cache.(*regexp2.Regexp); ok {
+			return re, nil
+		}
+	}
+
+	re, err := regexp2.Compile(pattern, options)
+	if err != nil {
+		return nil, err
+	}
+	re.MatchTimeout = RegexpMatchTimeout
+	regexpCache.Set(key, re)
+
+	return re, nil
+}
+
+// 正则匹配并返回命名分组
+func regexpSubmatch(pattern string, options regexp2.RegexOptions, s string) (map[string]string, error) {
+	resultMap := make(map[string]string)
+
+	re, err := CompileRegexp(pattern, options)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := re.FindStringMatch(s)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		gps := m.Groups()
+		for n, gp := range gps {
+			if n == 0 {
+				continue
+			}
+			resultMap[gp.Name] = gp.String()
+		}
+	}
+
+	return resultMap, nil
+}
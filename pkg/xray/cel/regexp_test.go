@@ -0,0 +1,94 @@
+package cel
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlclark/regexp2"
+)
+
+func TestCompileRegexpCache(t *testing.T) {
+	re1, err := CompileRegexp(`(?P<version>\d+\.\d+)`, regexp2.RE2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	re2, err := CompileRegexp(`(?P<version>\d+\.\d+)`, regexp2.RE2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if re1 != re2 {
+		t.Error("same pattern should return cached regexp")
+	}
+
+	re3, err := CompileRegexp(`\d+\.\d+`, regexp2.RE2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	re4, err := CompileRegexp(`\d+\.\d+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if re3 == re4 {
+		t.Error("different options should not share cached regexp")
+	}
+}
+
+func TestRegexpFunctions(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       interface{}
+	}{
+		{"matches", `"\\d+\\.\\d+".matches("pocV 1.2")`, true},
+		{"bmatches", `"\\d+\\.\\d+".bmatches(b"pocV 1.2")`, true},
+		{"submatch", `"(?P<version>\\d+\\.\\d+)".submatch("pocV 1.2")["version"]`, "1.2"},
+		{"bsubmatch", `"(?P<version>\\d+\\.\\d+)".bsubmatch(b"pocV 1.2")["version"]`, "1.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := evaluate(t, tt.expression); got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegexpFunctionErrors(t *testing.T) {
+	timeout := RegexpMatchTimeout
+	RegexpMatchTimeout = 100 * time.Millisecond
+	defer func() { RegexpMatchTimeout = timeout }()
+
+	body := strings.Repeat("a", 64) + "!"
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{"matches_invalid", `"(".matches("pocV")`},
+		{"bmatches_invalid", `"(".bmatches(b"pocV")`},
+		{"submatch_invalid", `"(?P<v>".submatch("pocV")`},
+		{"bsubmatch_invalid", `"[".bsubmatch(b"pocV")`},
+		{"bmatches_timeout", `"^(a|aa)+$".bmatches(b"` + body + `")`},
+		{"submatch_timeout", `"^(?P<v>(a+)+)$".submatch("` + body + `")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewEnvOption()
+			defer PutCustomLib(c)
+			env, err := NewEnv(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Now()
+			if _, err := Evaluate(env, tt.expression, map[string]interface{}{}); err == nil {
+				t.Errorf("%s should return error", tt.expression)
+			}
+			if time.Since(start) > 5*time.Second {
+				t.Errorf("%s should be interrupted by match timeout", tt.expression)
+			}
+		})
+	}
+}
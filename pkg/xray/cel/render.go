@@ -0,0 +1,174 @@
+package cel
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/WAY29/pocV/internal/common/errors"
+	"github.com/WAY29/pocV/pkg/xray/structs"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	// {{name}}、{{name.field}}、{{name|filter|filter}}，其他内容如{{7*7}}原样保留
+	// {{"..."}}输出引号中的原始内容，用于转义，如{{"{{config}}"}}输出{{config}}，{{"{{"}}输出{{
+	templateRegexp = regexp.MustCompile(`\{\{\s*(?:([A-Za-z_]\w*(?:\.[\w-]+)*)|"([^"]*)")\s*((?:\|\s*[A-Za-z_]\w*\s*)*)\}\}`)
+
+	// 模板过滤器，名称与cel函数保持一致
+	TemplateFilters = map[string]func(string) (string, error){
+		"urlencode": func(s string) (string, error) {
+			return url.QueryEscape(s), nil
+		},
+		"urldecode": url.QueryUnescape,
+		"base64": func(s string) (string, error) {
+			return base64.StdEncoding.EncodeToString([]byte(s)), nil
+		},
+		"base64Decode": func(s string) (string, error) {
+			decodeBytes, err := base64.StdEncoding.DecodeString(s)
+			return string(decodeBytes), err
+		},
+		"hexencode": func(s string) (string, error) {
+			return hex.EncodeToString([]byte(s)), nil
+		},
+		"hexdecode": func(s string) (string, error) {
+			decodeBytes, err := hex.DecodeString(s)
+			return string(decodeBytes), err
+		},
+		"md5": func(s string) (string, error) {
+			return fmt.Sprintf("%x", md5.Sum([]byte(s))), nil
+		},
+		"upper": func(s string) (string, error) {
+			return strings.ToUpper(s), nil
+		},
+		"lower": func(s string) (string, error) {
+			return strings.ToLower(s), nil
+		},
+		"trim": func(s string) (string, error) {
+			return strings.TrimSpace(s), nil
+		},
+	}
+)
+
+// 渲染模板，变量未定义、字段不存在或过滤器不存在时返回错误，需要原样保留的{{name}}使用{{"{{name}}"}}转义
+func RenderTemplate(template string, variableMap map[string]interface{}) (string, error) {
+	if !strings.Contains(template, "{{") {
+		return template, nil
+	}
+
+	var (
+		builder strings.Builder
+		last    int
+	)
+
+	for _, loc := range templateRegexp.FindAllStringSubmatchIndex(template, -1) {
+		builder.WriteString(template[last:loc[0]])
+		last = loc[1]
+
+		var (
+			value string
+			err   error
+		)
+		if loc[2] >= 0 {
+			if value, err = renderVariable(template[loc[2]:loc[3]], variableMap); err != nil {
+				return "", err
+			}
+		} else {
+			value = template[loc[4]:loc[5]]
+		}
+
+		for _, name := range strings.Split(template[loc[6]:loc[7]], "|")[1:] {
+			name = strings.TrimSpace(name)
+			filter, ok := TemplateFilters[name]
+			if !ok {
+				return "", errors.Newf(errors.TemplateError, "Unknown template filter: %s", name)
+			}
+			if value, err = filter(value); err != nil {
+				return "", errors.Newf(errors.TemplateError, "Template filter %s error: %v", name, err)
+			}
+		}
+
+		builder.WriteString(value)
+	}
+	builder.WriteString(template[last:])
+
+	return builder.String(), nil
+}
+
+// 获取变量值，支持通过.访问proto字段和map的键
+func renderVariable(name string, variableMap map[string]interface{}) (string, error) {
+	fields := strings.Split(name, ".")
+
+	value, ok := variableMap[fields[0]]
+	if !ok {
+		return "", errors.Newf(errors.TemplateError, "Undefined template variable: %s", fields[0])
+	}
+
+	for i, field := range fields[1:] {
+		if value, ok = lookupField(value, field); !ok {
+			return "", errors.Newf(errors.TemplateError, "Undefined template variable: %s", strings.Join(fields[:i+2], "."))
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case *structs.UrlType:
+		return UrlTypeToString(v), nil
+	case proto.Message, map[string]string, map[string]interface{}:
+		return "", errors.Newf(errors.TemplateError, "Template variable %s can not be rendered, specify a field", name)
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
+}
+
+func lookupField(value interface{}, field string) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]string:
+		result, ok := v[field]
+		return result, ok
+	case map[string]interface{}:
+		result, ok := v[field]
+		return result, ok
+	case proto.Message:
+		message := v.ProtoReflect()
+		fd := message.Descriptor().Fields().ByName(protoreflect.Name(field))
+		if fd == nil {
+			return nil, false
+		}
+		fieldValue := message.Get(fd)
+
+		switch {
+		case fd.IsMap():
+			result := make(map[string]string, fieldValue.Map().Len())
+			fieldValue.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				result[k.String()] = v.String()
+				return true
+			})
+			return result, true
+		case fd.IsList():
+			return nil, false
+		case fd.Message() != nil:
+			if !message.Has(fd) {
+				return nil, false
+			}
+			return fieldValue.Message().Interface(), true
+		case fd.Enum() != nil:
+			if enumValue := fd.Enum().Values().ByNumber(fieldValue.Enum()); enumValue != nil {
+				return string(enumValue.Name()), true
+			}
+			return fieldValue.Enum(), true
+		default:
+			return fieldValue.Interface(), true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,86 @@
+package cel
+
+import (
+	"testing"
+
+	"github.com/WAY29/pocV/pkg/xray/structs"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	variableMap := map[string]interface{}{
+		"payload": "a b&c",
+		"num":     int64(42),
+		"match":   map[string]string{"version": "2.14.1"},
+		"reverse": &structs.Reverse{
+			Domain:      "abc.dnslog.cn",
+			Url:         &structs.UrlType{Scheme: "http", Host: "abc.dnslog.cn", Path: "/x"},
+			ReverseType: structs.ReverseType_DnslogCN,
+		},
+		"request": &structs.Request{
+			Url:     &structs.UrlType{Scheme: "http", Host: "127.0.0.1:8080", Path: "/"},
+			Headers: map[string]string{"User-Agent": "pocV"},
+		},
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"/index?q={{payload}}", "/index?q=a b&c"},
+		{"/index?q={{ payload | urlencode }}", "/index?q=a+b%26c"},
+		{"{{payload|base64|md5}}", "bd989345ac09536be73cd684e048e525"},
+		{"{{num}}", "42"},
+		{"{{match.version}}", "2.14.1"},
+		{"{{reverse.domain}}", "abc.dnslog.cn"},
+		{"{{reverse.url}}", "http://abc.dnslog.cn/x"},
+		{"{{reverse.reverse_type}}", "DnslogCN"},
+		{"{{request.url.host}}", "127.0.0.1:8080"},
+		{"{{request.headers.User-Agent|upper}}", "POCV"},
+		{"{{7*7}}${{'a'}}", "{{7*7}}${{'a'}}"},
+		{"no template", "no template"},
+		// 转义未定义的变量名，如ssti payload
+		{`{{"{{config}}"}}`, "{{config}}"},
+		{`{{"{{"}}self{{"}}"}}`, "{{self}}"},
+		{`{{ "{{request}}" | urlencode }}`, "%7B%7Brequest%7D%7D"},
+		{`{{""}}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			got, err := RenderTemplate(tt.template, variableMap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	variableMap := map[string]interface{}{
+		"payload": "pocV",
+		"match":   map[string]string{"version": "2.14.1"},
+		"reverse": &structs.Reverse{Domain: "abc.dnslog.cn"},
+	}
+
+	tests := []string{
+		"{{unknown}}",
+		"{{payload|unknown}}",
+		"{{payload|hexdecode}}",
+		"{{match.name}}",
+		"{{match}}",
+		"{{reverse}}",
+		"{{reverse.url}}",
+		"{{reverse.unknown}}",
+	}
+
+	for _, template := range tests {
+		t.Run(template, func(t *testing.T) {
+			if _, err := RenderTemplate(template, variableMap); err == nil {
+				t.Errorf("RenderTemplate(%q) should return error", template)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package cel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/interpreter/functions"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// 版本比较和时间函数
+var (
+	VersionDeclarations = []*exprpb.Decl{
+		decls.NewFunction("versionGreater",
+			decls.NewOverload("versionGreater_string_string",
+				[]*exprpb.Type{decls.String, decls.String},
+				decls.Bool)),
+		decls.NewFunction("versionLess",
+			decls.NewOverload("versionLess_string_string",
+				[]*exprpb.Type{decls.String, decls.String},
+				decls.Bool)),
+		decls.NewFunction("versionEqual",
+			decls.NewOverload("versionEqual_string_string",
+				[]*exprpb.Type{decls.String, decls.String},
+				decls.Bool)),
+		// versionBetween(version, low, high)，包含low和high
+		decls.NewFunction("versionBetween",
+			decls.NewOverload("versionBetween_string_string_string",
+				[]*exprpb.Type{decls.String, decls.String, decls.String},
+				decls.Bool)),
+		decls.NewFunction("timestamp_second",
+			decls.NewOverload("timestamp_second",
+				[]*exprpb.Type{},
+				decls.Int)),
+		// year/shortyear/month/day([offset])，返回当前时间加上offset后的值
+		decls.NewFunction("year",
+			decls.NewOverload("year",
+				[]*exprpb.Type{},
+				decls.String),
+			decls.NewOverload("year_int",
+				[]*exprpb.Type{decls.Int},
+				decls.String)),
+		decls.NewFunction("shortyear",
+			decls.NewOverload("shortyear",
+				[]*exprpb.Type{},
+				decls.String),
+			decls.NewOverload("shortyear_int",
+				[]*exprpb.Type{decls.Int},
+				decls.String)),
+		decls.NewFunction("month",
+			decls.NewOverload("month",
+				[]*exprpb.Type{},
+				decls.String),
+			decls.NewOverload("month_int",
+				[]*exprpb.Type{decls.Int},
+				decls.String)),
+		decls.NewFunction("day",
+			decls.NewOverload("day",
+				[]*exprpb.Type{},
+				decls.String),
+			decls.NewOverload("day_int",
+				[]*exprpb.Type{decls.Int},
+				decls.String)),
+	}
+
+	VersionOverloads = concatOverloads(
+		[]*functions.Overload{
+			versionCompareOverload("versionGreater_string_string", "versionGreater", func(r int) bool { return r > 0 }),
+			versionCompareOverload("versionLess_string_string", "versionLess", func(r int) bool { return r < 0 }),
+			versionCompareOverload("versionEqual_string_string", "versionEqual", func(r int) bool { return r == 0 }),
+			{
+				Operator: "versionBetween_string_string_string",
+				Function: func(values ...ref.Val) ref.Val {
+					if len(values) != 3 {
+						return types.NewErr("invalid arguments to 'versionBetween'")
+					}
+					version, ok := values[0].(types.String)
+					if !ok {
+						return types.ValOrErr(values[0], "unexpected type '%v' passed to versionBetween", values[0].Type())
+					}
+					low, ok := values[1].(types.String)
+					if !ok {
+						return types.ValOrErr(values[1], "unexpected type '%v' passed to versionBetween", values[1].Type())
+					}
+					high, ok := values[2].(types.String)
+					if !ok {
+						return types.ValOrErr(values[2], "unexpected type '%v' passed to versionBetween", values[2].Type())
+					}
+					return types.Bool(CompareVersion(string(version), string(low)) >= 0 && CompareVersion(string(version), string(high)) <= 0)
+				},
+			},
+			{
+				Operator: "timestamp_second",
+				Function: func(values ...ref.Val) ref.Val {
+					return types.Int(time.Now().Unix())
+				},
+			},
+		},
+		timeOverloads("year", func(offset int) string {
+			return strconv.Itoa(time.Now().AddDate(offset, 0, 0).Year())
+		}),
+		timeOverloads("shortyear", func(offset int) string {
+			return fmt.Sprintf("%02d", time.Now().AddDate(offset, 0, 0).Year()%100)
+		}),
+		timeOverloads("month", func(offset int) string {
+			return fmt.Sprintf("%02d", int(time.Now().AddDate(0, offset, 0).Month()))
+		}),
+		timeOverloads("day", func(offset int) string {
+			return fmt.Sprintf("%02d", time.Now().AddDate(0, 0, offset).Day())
+		}),
+	)
+
+	// 分隔符后的预发布版本标识及其顺序，其他后缀视为厂商后缀，不参与比较
+	preReleaseOrder = map[string]int{
+		"dev":      0,
+		"snapshot": 0,
+		"alpha":    1,
+		"a":        1,
+		"beta":     2,
+		"b":        2,
+		"m":        3,
+		"pre":      4,
+		"rc":       5,
+		"cr":       5,
+	}
+)
+
+func versionCompareOverload(operator, name string, fn func(int) bool) *functions.Overload {
+	return &functions.Overload{
+		Operator: operator,
+		Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
+			v1, ok := lhs.(types.String)
+			if !ok {
+				return types.ValOrErr(lhs, "unexpected type '%v' passed to %s", lhs.Type(), name)
+			}
+			v2, ok := rhs.(types.String)
+			if !ok {
+				return types.ValOrErr(rhs, "unexpected type '%v' passed to %s", rhs.Type(), name)
+			}
+			return types.Bool(fn(CompareVersion(string(v1), string(v2))))
+		},
+	}
+}
+
+// 生成无参数及带offset参数的时间函数
+func timeOverloads(name string, fn func(int) string) []*functions.Overload {
+	return []*functions.Overload{
+		{
+			Operator: name,
+			Function: func(values ...ref.Val) ref.Val {
+				return types.String(fn(0))
+			},
+		},
+		{
+			Operator: name + "_int",
+			Unary: func(value ref.Val) ref.Val {
+				v, ok := value.(types.Int)
+				if !ok {
+					return types.ValOrErr(value, "unexpected type '%v' passed to %s", value.Type(), name)
+				}
+				return types.String(fn(int(v)))
+			},
+		},
+	}
+}
+
+type version struct {
+	numbers []int
+	// 紧跟数字的字母子版本，如1.1.1k中的k、8.0p1中的p和1
+	letters      string
+	letterNumber int
+	// 预发布版本顺序，正式版本为-1
+	preRelease       int
+	preReleaseNumber int
+}
+
+// 解析版本号，如v1.2.3、2.14.1-rc1、5.7.31-log、1.0.0.Final、1.1.1k
+func parseVersion(s string) version {
+	v := version{preRelease: -1}
+
+	s = strings.TrimLeft(strings.ToLower(strings.TrimSpace(s)), "v")
+	i := 0
+	for i < len(s) {
+		j := i
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		if j == i {
+			break
+		}
+		n, _ := strconv.Atoi(s[i:j])
+		v.numbers = append(v.numbers, n)
+		i = j
+		if i < len(s) && s[i] == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9' {
+			i++
+			continue
+		}
+		break
+	}
+
+	// 紧跟数字的字母视为子版本参与比较，如OpenSSL的1.1.1k、OpenSSH的8.0p1，2.0rc1这类完整的预发布标识除外
+	if len(v.numbers) > 0 {
+		j := 0
+		for j < len(s[i:]) && s[i+j] >= 'a' && s[i+j] <= 'z' {
+			j++
+		}
+		if _, ok := preReleaseOrder[s[i:i+j]]; j == 1 || (j > 1 && !ok) {
+			v.letters = s[i : i+j]
+			k := i + j
+			for k < len(s) && s[k] >= '0' && s[k] <= '9' {
+				k++
+			}
+			v.letterNumber, _ = strconv.Atoi(s[i+j : k])
+			return v
+		}
+	}
+
+	// 解析后缀中的预发布标识，如-rc1、.beta2、rc1
+	suffix := strings.TrimLeft(s[i:], "-._+~")
+	j := 0
+	for j < len(suffix) && suffix[j] >= 'a' && suffix[j] <= 'z' {
+		j++
+	}
+	if order, ok := preReleaseOrder[suffix[:j]]; ok && j > 0 {
+		rest := strings.TrimLeft(suffix[j:], "-._")
+		k := 0
+		for k < len(rest) && rest[k] >= '0' && rest[k] <= '9' {
+			k++
+		}
+		// 分隔符后的a/b/m只有后跟数字或结束时才视为预发布标识，避免误判厂商后缀
+		if len(suffix[:j]) > 1 || k > 0 || len(rest) == 0 {
+			v.preRelease = order
+			v.preReleaseNumber, _ = strconv.Atoi(rest[:k])
+		}
+	}
+
+	return v
+}
+
+// 比较版本号，a>b返回1，a<b返回-1，相等返回0
+func CompareVersion(a, b string) int {
+	va, vb := parseVersion(a), parseVersion(b)
+
+	length := len(va.numbers)
+	if len(vb.numbers) > length {
+		length = len(vb.numbers)
+	}
+	for i := 0; i < length; i++ {
+		var na, nb int
+		if i < len(va.numbers) {
+			na = va.numbers[i]
+		}
+		if i < len(vb.numbers) {
+			nb = vb.numbers[i]
+		}
+		if na != nb {
+			return compareInt(na, nb)
+		}
+	}
+
+	// 字母子版本按字典序比较，没有子版本的最小
+	if va.letters != vb.letters {
+		return strings.Compare(va.letters, vb.letters)
+	}
+	if va.letterNumber != vb.letterNumber {
+		return compareInt(va.letterNumber, vb.letterNumber)
+	}
+
+	// 正式版本大于预发布版本
+	if va.preRelease != vb.preRelease {
+		if va.preRelease == -1 {
+			return 1
+		}
+		if vb.preRelease == -1 {
+			return -1
+		}
+		return compareInt(va.preRelease, vb.preRelease)
+	}
+	return compareInt(va.preReleaseNumber, vb.preReleaseNumber)
+}
+
+func compareInt(a, b int) int {
+	if a > b {
+		return 1
+	} else if a < b {
+		return -1
+	}
+	return 0
+}
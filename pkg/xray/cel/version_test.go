@@ -0,0 +1,84 @@
+package cel
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCompareVersion(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.14.1", "2.14.1", 0},
+		{"v2.14.1", "2.14.1", 0},
+		{"2.14", "2.14.0", 0},
+		{"2.15.0", "2.14.1", 1},
+		{"2.9.1", "2.14.1", -1},
+		{"2.14.1-rc1", "2.14.1", -1},
+		{"2.14.1-rc2", "2.14.1-rc1", 1},
+		{"2.14.1-beta", "2.14.1-rc1", -1},
+		{"2.14.1-alpha1", "2.14.1.beta1", -1},
+		{"5.7.31-log", "5.7.31", 0},
+		{"5.7.31-0ubuntu0.18.04.1", "5.7.31", 0},
+		{"1.0.0.Final", "1.0.0", 0},
+		{"4.3.2.RELEASE", "4.3.10", -1},
+		{"2.14.1rc1", "2.14.1", -1},
+		{"2.0beta2", "2.0beta10", -1},
+		{"7.0.1-a1", "7.0.1", -1},
+		{"7.0.1.b1", "7.0.1-a2", 1},
+		// 紧跟数字的字母为子版本
+		{"1.1.1a", "1.1.1", 1},
+		{"1.1.1k", "1.1.1", 1},
+		{"1.1.1k", "1.1.1t", -1},
+		{"1.1.1t", "1.1.1t", 0},
+		{"1.0.2z", "1.0.2zh", -1},
+		{"1.0.2zh", "1.1.0", -1},
+		{"8.0p1", "8.0p2", -1},
+		{"7.9p1", "8.0", -1},
+		{"1.1.1k", "1.1.1-rc1", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			if got := CompareVersion(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareVersion(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionFunctions(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		expression string
+		want       interface{}
+	}{
+		{"versionGreater", `versionGreater("2.15.0", "2.14.1")`, true},
+		{"versionLess", `versionLess("2.14.1-rc1", "2.14.1")`, true},
+		{"versionLess_letter", `versionLess("1.1.1k", "1.1.1t")`, true},
+		{"versionLess_letter_release", `versionLess("1.1.1a", "1.1.1")`, false},
+		{"versionEqual", `versionEqual("v1.2", "1.2.0")`, true},
+		{"versionBetween", `versionBetween("2.10.0", "2.0", "2.14.1")`, true},
+		{"versionBetween_high", `versionBetween("2.14.1", "2.0", "2.14.1")`, true},
+		{"versionBetween_out", `versionBetween("2.15.0", "2.0", "2.14.1")`, false},
+		{"timestamp_second", `timestamp_second() >= ` + strconv.FormatInt(now.Unix(), 10), true},
+		{"year", `year()`, strconv.Itoa(now.Year())},
+		{"year_zero", `year(0)`, strconv.Itoa(now.Year())},
+		{"year_offset", `year(-1)`, strconv.Itoa(now.Year() - 1)},
+		{"shortyear_offset", `shortyear(1)`, now.AddDate(1, 0, 0).Format("06")},
+		{"shortyear", `shortyear()`, now.Format("06")},
+		{"month", `month(0)`, now.Format("01")},
+		{"day", `day(0)`, now.Format("02")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := evaluate(t, tt.expression); got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package parse
 
 import (
 	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/WAY29/errors"
@@ -40,5 +41,9 @@ func ParsePoc(filename string) (*structs.Poc, error) {
 	if poc.Transport == "" {
 		poc.Transport = "http"
 	}
+
+	if err = parsePayloads(&poc.Payloads, filepath.Dir(filename)); err != nil {
+		return nil, errors.Wrapf(err, "Xray poc[%s] parse payloads error", filename)
+	}
 	return poc, nil
 }
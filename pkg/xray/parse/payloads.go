@@ -0,0 +1,74 @@
+package parse
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/WAY29/errors"
+	"github.com/WAY29/pocV/pkg/xray/structs"
+	"github.com/WAY29/pocV/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// 解析payloads，cartesian/pitchfork模式下变量取值为列表或{file: path}，相对路径基于poc文件所在目录
+func parsePayloads(payloads *structs.Payloads, dir string) error {
+	payloads.Variables = nil
+
+	switch payloads.Mode {
+	case "":
+		for _, item := range payloads.Payloads {
+			if _, ok := item.Value.(yaml.MapSlice); !ok {
+				return errors.Newf("Payload set[%v] must be a map", item.Key)
+			}
+		}
+		return nil
+	case structs.PayloadModeCartesian, structs.PayloadModePitchfork:
+	default:
+		return errors.Newf("Unknown payloads mode: %s", payloads.Mode)
+	}
+
+	for _, item := range payloads.Payloads {
+		name, ok := item.Key.(string)
+		if !ok {
+			return errors.Newf("Payload variable[%v] name must be string", item.Key)
+		}
+
+		variable := structs.PayloadVariable{Name: name}
+		switch value := item.Value.(type) {
+		case []interface{}:
+			variable.Values = make([]string, 0, len(value))
+			for _, v := range value {
+				variable.Values = append(variable.Values, fmt.Sprintf("%v", v))
+			}
+		case yaml.MapSlice:
+			var file string
+			for _, option := range value {
+				if option.Key == "file" {
+					file, _ = option.Value.(string)
+				}
+			}
+			if file == "" {
+				return errors.Newf("Payload variable[%s] must be a list or {file: path}", name)
+			}
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			lines, err := utils.ReadFileAsLine(file)
+			if err != nil {
+				return errors.Wrapf(err, "Read payload file[%s] error", file)
+			}
+			for _, line := range lines {
+				if line = strings.TrimRight(line, "\r"); line != "" {
+					variable.Values = append(variable.Values, line)
+				}
+			}
+		default:
+			return errors.Newf("Payload variable[%s] must be a list or {file: path}", name)
+		}
+
+		payloads.Variables = append(payloads.Variables, variable)
+	}
+
+	return nil
+}
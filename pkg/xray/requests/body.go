@@ -0,0 +1,131 @@
+package requests
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/WAY29/pocV/internal/common/errors"
+	"github.com/WAY29/pocV/utils"
+	"github.com/andybalholm/brotli"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/transform"
+)
+
+var (
+	// 响应体最大读取长度，0为不限制
+	MaxBodySize int64 = 10 * 1024 * 1024
+	// 是否将非UTF-8响应体转码
+	DecodeCharset bool
+
+	transcodeCharsets = map[string]bool{
+		"gbk":       true,
+		"gb18030":   true,
+		"big5":      true,
+		"shift_jis": true,
+	}
+)
+
+// 读取原始响应体，超过MaxBodySize的部分会被丢弃
+func ReadRawBody(oResp *http.Response) ([]byte, error) {
+	var reader io.Reader = oResp.Body
+	if MaxBodySize > 0 {
+		reader = io.LimitReader(reader, MaxBodySize)
+	}
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		wrappedErr := errors.Newf(errors.ResponseError, "Get response body error: %v", err)
+		return nil, wrappedErr
+	}
+	return body, nil
+}
+
+// 读取并解码响应体，解码失败时返回原始响应体
+func GetRespBody(oResp *http.Response) ([]byte, error) {
+	body, err := ReadRawBody(oResp)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := DecodeBody(body, oResp.Header.Get("Content-Encoding"))
+	if err != nil {
+		utils.DebugF("Decode response body error: %v", err)
+		return body, nil
+	}
+	return decoded, nil
+}
+
+// 按Content-Encoding逆序解码响应体，支持gzip/deflate/br
+func DecodeBody(body []byte, contentEncoding string) ([]byte, error) {
+	if contentEncoding == "" {
+		return body, nil
+	}
+
+	encodings := strings.Split(contentEncoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var (
+			decoder io.Reader
+			reader  io.Reader
+			err     error
+		)
+
+		switch strings.ToLower(strings.TrimSpace(encodings[i])) {
+		case "gzip", "x-gzip":
+			decoder, err = gzip.NewReader(bytes.NewReader(body))
+		case "deflate":
+			// 部分服务器返回不带zlib头的deflate数据
+			decoder, err = zlib.NewReader(bytes.NewReader(body))
+			if err != nil {
+				decoder, err = flate.NewReader(bytes.NewReader(body)), nil
+			}
+		case "br":
+			decoder = brotli.NewReader(bytes.NewReader(body))
+		case "identity", "":
+			continue
+		default:
+			return nil, errors.Newf(errors.ResponseError, "Unsupported Content-Encoding: %s", encodings[i])
+		}
+		if err != nil {
+			return nil, errors.Newf(errors.ResponseError, "Decode %s body error: %v", encodings[i], err)
+		}
+
+		// 限制解码后的长度
+		reader = decoder
+		if MaxBodySize > 0 {
+			reader = io.LimitReader(decoder, MaxBodySize)
+		}
+		body, err = ioutil.ReadAll(reader)
+		if closer, ok := decoder.(io.Closer); ok {
+			closer.Close()
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return nil, errors.Newf(errors.ResponseError, "Decode %s body error: %v", encodings[i], err)
+		}
+	}
+
+	return body, nil
+}
+
+// 将GBK/Big5/Shift-JIS响应体转码为UTF-8，返回转码后的响应体和字符集，无需转码时原样返回
+func TranscodeBody(body []byte, contentType string) ([]byte, string) {
+	if !DecodeCharset || len(body) == 0 {
+		return body, ""
+	}
+
+	encoding, name, _ := charset.DetermineEncoding(body, contentType)
+	if !transcodeCharsets[name] {
+		return body, ""
+	}
+
+	utf8Body, _, err := transform.Bytes(encoding.NewDecoder(), body)
+	if err != nil {
+		utils.DebugF("Transcode response body from %s error: %v", name, err)
+		return body, ""
+	}
+	return utf8Body, name
+}
@@ -0,0 +1,175 @@
+package requests
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	var (
+		buf    bytes.Buffer
+		writer io.WriteCloser
+	)
+
+	switch encoding {
+	case "gzip":
+		writer = gzip.NewWriter(&buf)
+	case "zlib":
+		writer = zlib.NewWriter(&buf)
+	case "flate":
+		writer, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		writer = brotli.NewWriter(&buf)
+	default:
+		t.Fatalf("unknown encoding %s", encoding)
+	}
+	writer.Write(data)
+	writer.Close()
+
+	return buf.Bytes()
+}
+
+func TestDecodeBody(t *testing.T) {
+	// 超过解码器内部缓冲区大小，用于检查多次读取后的拼接
+	large := []byte(strings.Repeat("0123456789abcdef", 16*1024))
+	for i := range large {
+		large[i] ^= byte(i / 7)
+	}
+	small := []byte("<html><title>pocV</title></html>")
+
+	tests := []struct {
+		name            string
+		contentEncoding string
+		body            []byte
+		want            []byte
+		wantErr         bool
+	}{
+		{"empty encoding", "", small, small, false},
+		{"identity", "identity", small, small, false},
+		{"gzip", "gzip", compress(t, "gzip", large), large, false},
+		{"x-gzip", "X-Gzip", compress(t, "gzip", small), small, false},
+		{"deflate with zlib header", "deflate", compress(t, "zlib", large), large, false},
+		{"deflate without zlib header", "deflate", compress(t, "flate", large), large, false},
+		{"br", "br", compress(t, "br", large), large, false},
+		{"stacked", "deflate, gzip", compress(t, "gzip", compress(t, "zlib", small)), small, false},
+		{"stacked with br", "br,identity, gzip", compress(t, "gzip", compress(t, "br", small)), small, false},
+		{"unsupported", "compress", small, nil, true},
+		{"invalid gzip", "gzip", small, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := DecodeBody(tt.body, tt.contentEncoding)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: DecodeBody() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: DecodeBody() got %d bytes, want %d bytes", tt.name, len(got), len(tt.want))
+		}
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	oldMaxBodySize := MaxBodySize
+	defer func() { MaxBodySize = oldMaxBodySize }()
+	MaxBodySize = 10
+
+	data := []byte(strings.Repeat("a", 100))
+
+	// 解码后的长度受限制
+	for _, contentEncoding := range []string{"gzip", "br"} {
+		got, err := DecodeBody(compress(t, contentEncoding, data), contentEncoding)
+		if err != nil || !bytes.Equal(got, data[:10]) {
+			t.Errorf("%s: DecodeBody() = %q, %v", contentEncoding, got, err)
+		}
+	}
+
+	// 原始响应体的长度受限制
+	oResp := &http.Response{Body: ioutil.NopCloser(bytes.NewReader(data)), Header: http.Header{}}
+	if got, err := ReadRawBody(oResp); err != nil || !bytes.Equal(got, data[:10]) {
+		t.Errorf("ReadRawBody() = %q, %v", got, err)
+	}
+
+	MaxBodySize = 0
+	oResp = &http.Response{Body: ioutil.NopCloser(bytes.NewReader(data)), Header: http.Header{}}
+	if got, err := ReadRawBody(oResp); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("ReadRawBody() without limit got %d bytes, %v", len(got), err)
+	}
+}
+
+func TestGetRespBody(t *testing.T) {
+	data := []byte("hello pocV")
+	oResp := &http.Response{
+		Body:   ioutil.NopCloser(bytes.NewReader(compress(t, "gzip", data))),
+		Header: http.Header{"Content-Encoding": {"gzip"}},
+	}
+	if got, err := GetRespBody(oResp); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("GetRespBody() = %q, %v", got, err)
+	}
+
+	// 解码失败时返回原始响应体
+	oResp = &http.Response{
+		Body:   ioutil.NopCloser(bytes.NewReader(data)),
+		Header: http.Header{"Content-Encoding": {"gzip"}},
+	}
+	if got, err := GetRespBody(oResp); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("GetRespBody() invalid gzip = %q, %v", got, err)
+	}
+}
+
+func TestTranscodeBody(t *testing.T) {
+	oldDecodeCharset := DecodeCharset
+	defer func() { DecodeCharset = oldDecodeCharset }()
+	DecodeCharset = true
+
+	encode := func(e encoding.Encoding, s string) []byte {
+		b, err := e.NewEncoder().Bytes([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+		wantCharset string
+	}{
+		{"gbk", encode(simplifiedchinese.GBK, "<title>管理后台</title>"), "text/html; charset=GBK", "<title>管理后台</title>", "gbk"},
+		{"gb2312 meta", encode(simplifiedchinese.GBK, `<meta charset="gb2312"><title>登录</title>`), "text/html", `<meta charset="gb2312"><title>登录</title>`, "gbk"},
+		{"gb18030", encode(simplifiedchinese.GB18030, "系统"), "text/plain; charset=gb18030", "系统", "gb18030"},
+		{"big5", encode(traditionalchinese.Big5, "<title>系統管理</title>"), "text/html; charset=big5", "<title>系統管理</title>", "big5"},
+		{"shift_jis", encode(japanese.ShiftJIS, "<title>ログイン</title>"), "text/html; charset=Shift_JIS", "<title>ログイン</title>", "shift_jis"},
+		{"utf-8", []byte("<title>管理后台</title>"), "text/html; charset=utf-8", "<title>管理后台</title>", ""},
+		{"empty", nil, "text/html; charset=gbk", "", ""},
+	}
+
+	for _, tt := range tests {
+		got, charset := TranscodeBody(tt.body, tt.contentType)
+		if string(got) != tt.want || charset != tt.wantCharset {
+			t.Errorf("%s: TranscodeBody() = %q, %q, want %q, %q", tt.name, got, charset, tt.want, tt.wantCharset)
+		}
+	}
+
+	// 未开启时原样返回
+	DecodeCharset = false
+	body := encode(simplifiedchinese.GBK, "管理后台")
+	if got, charset := TranscodeBody(body, "text/html; charset=gbk"); !bytes.Equal(got, body) || charset != "" {
+		t.Errorf("TranscodeBody() disabled = %q, %q", got, charset)
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"net"
 	"net/http"
 	"sort"
+	"sync"
+	"sync/atomic"
 
+	"github.com/WAY29/pocV/internal/common/metrics"
 	"github.com/WAY29/pocV/pkg/xray/structs"
 	"github.com/WAY29/pocV/utils"
 	"github.com/bluele/gcache"
@@ -13,13 +16,18 @@ import (
 
 var (
 	GC gcache.Cache
+
+	connectionSessionID uint64
+	// 未关闭的连接会话，退出时统一关闭
+	connectionSessions     = make(map[*ConnectionSession]struct{})
+	connectionSessionsLock sync.Mutex
 )
 
 func InitCache(size int) {
 	GC = gcache.New(size).ARC().Build()
 }
 
-func getHttpRuleHash(req *structs.RuleRequest) string {
+func getHttpRuleHash(target string, req *structs.RuleRequest) string {
 	headers := req.Headers
 	keys := make([]string, len(headers))
 	headerStirng := ""
@@ -33,12 +41,12 @@ func getHttpRuleHash(req *structs.RuleRequest) string {
 		headerStirng += fmt.Sprintf("%s%s", k, headers[k])
 	}
 
-	return "rule_" + utils.MD5(fmt.Sprintf("%s%s%s%s%v", req.Method, req.Path, headerStirng, req.Body, req.FollowRedirects))
+	return "rule_" + utils.MD5(fmt.Sprintf("%s%s%s%s%s%v%s%v%v%s%s%s", target, req.Method, req.Path, headerStirng, req.Body, req.FollowRedirects, req.Raw, req.Unsafe, req.Http2, req.Scheme, req.Host, req.Port))
 }
 
-func XraySetHttpRequestCache(ruleReq *structs.RuleRequest, request *http.Request, protoRequest *structs.Request, protoResponse *structs.Response) bool {
+func XraySetHttpRequestCache(target string, ruleReq *structs.RuleRequest, request *http.Request, protoRequest *structs.Request, protoResponse *structs.Response) bool {
 
-	ruleHash := getHttpRuleHash(ruleReq)
+	ruleHash := getHttpRuleHash(target, ruleReq)
 
 	if cache, err := GC.Get(ruleHash); err != nil {
 		if _, ok := cache.(*structs.HttpRequestCache); ok {
@@ -57,51 +65,103 @@ func XraySetHttpRequestCache(ruleReq *structs.RuleRequest, request *http.Request
 	return false
 }
 
-func XrayGetHttpRequestCache(ruleReq *structs.RuleRequest) (*http.Request, *structs.Request, *structs.Response, bool) {
-	ruleHash := getHttpRuleHash(ruleReq)
+func XrayGetHttpRequestCache(target string, ruleReq *structs.RuleRequest) (*http.Request, *structs.Request, *structs.Response, bool) {
+	ruleHash := getHttpRuleHash(target, ruleReq)
 
 	if cache, err := GC.Get(ruleHash); err == nil {
 		if requestCache, ok := cache.(*structs.HttpRequestCache); ok {
+			metrics.ObserveCache("http", true)
 			return requestCache.Request, requestCache.ProtoRequest, requestCache.ProtoResponse, true
 		} else {
 		}
 	}
 
+	metrics.ObserveCache("http", false)
 	return nil, nil, nil, false
 }
 
-func getConnectionIdHash(connectionId string) string {
-	return "connetionID_" + connectionId
+// 一次poc执行中connection_id对应的连接，poc执行结束时关闭
+// 连接不放入会淘汰的缓存中，避免poc执行过程中连接被关闭
+type ConnectionSession struct {
+	name  string
+	conns map[string]net.Conn
+	lock  sync.Mutex
 }
 
-func XraySetTcpUdpConnectionCache(connectionId string, conn *net.Conn) bool {
-	connectionIdHash := getConnectionIdHash(connectionId)
-	if err := GC.Set(connectionIdHash, conn); err == nil {
-		return true
+func NewConnectionSession(target, pocName string) *ConnectionSession {
+	id := atomic.AddUint64(&connectionSessionID, 1)
+	return &ConnectionSession{
+		name:  fmt.Sprintf("connetionID_%d_%s_%s", id, target, pocName),
+		conns: make(map[string]net.Conn),
 	}
+}
 
-	return false
+func (s *ConnectionSession) Set(connectionId string, conn net.Conn) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// 覆盖同一connection_id时关闭旧连接
+	if old, ok := s.conns[connectionId]; ok && old != conn {
+		closeConnection(s.name, connectionId, old)
+	}
+	s.conns[connectionId] = conn
+
+	connectionSessionsLock.Lock()
+	connectionSessions[s] = struct{}{}
+	connectionSessionsLock.Unlock()
+
+	return true
 }
 
-func XrayGetTcpUdpConnectionCache(connectionId string) (*net.Conn, bool) {
-	connectionIdHash := getConnectionIdHash(connectionId)
+func (s *ConnectionSession) Get(connectionId string) (net.Conn, bool) {
+	s.lock.Lock()
+	conn, ok := s.conns[connectionId]
+	s.lock.Unlock()
 
-	if cache, err := GC.Get(connectionIdHash); err == nil {
-		if connectionCache, ok := cache.(*net.Conn); ok {
-			return connectionCache, true
-		} else {
-		}
+	metrics.ObserveCache("connection", ok)
+	return conn, ok
+}
+
+// 关闭会话中的所有连接
+func (s *ConnectionSession) Close() {
+	connectionSessionsLock.Lock()
+	delete(connectionSessions, s)
+	connectionSessionsLock.Unlock()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for connectionId, conn := range s.conns {
+		closeConnection(s.name, connectionId, conn)
+		delete(s.conns, connectionId)
 	}
+}
+
+// 关闭所有未关闭会话中的连接
+func CloseConnections() {
+	connectionSessionsLock.Lock()
+	sessions := make([]*ConnectionSession, 0, len(connectionSessions))
+	for session := range connectionSessions {
+		sessions = append(sessions, session)
+	}
+	connectionSessionsLock.Unlock()
+
+	for _, session := range sessions {
+		session.Close()
+	}
+}
 
-	return nil, false
+func closeConnection(sessionName, connectionId string, conn net.Conn) {
+	conn.Close()
+	utils.DebugF("Close connection[%s_%s]", sessionName, connectionId)
 }
 
-func getTCPUDPResponseHash(content string) string {
-	return "tcpudpResponse_" + content
+// 相同内容在是否使用tls、不同读取方式下的响应不同
+func getTCPUDPResponseHash(target string, ruleReq *structs.RuleRequest, tls bool, content string) string {
+	return "tcpudpResponse_" + utils.MD5(fmt.Sprintf("%s|%v|%d|%s|%d|%s", target, tls, ruleReq.ReadSize, ruleReq.ReadUntil, ruleReq.Datagrams, content))
 }
 
-func XraySetTcpUdpResponseCache(content string, response []byte, protoResponse *structs.Response) bool {
-	responseHash := getTCPUDPResponseHash(content)
+func XraySetTcpUdpResponseCache(target string, ruleReq *structs.RuleRequest, tls bool, content string, response []byte, protoResponse *structs.Response) bool {
+	responseHash := getTCPUDPResponseHash(target, ruleReq, tls, content)
 
 	if cache, err := GC.Get(responseHash); err != nil {
 		if _, ok := cache.(*structs.TCPUDPRequestCache); ok {
@@ -119,14 +179,16 @@ func XraySetTcpUdpResponseCache(content string, response []byte, protoResponse *
 	return false
 }
 
-func XrayGetTcpUdpResponseCache(content string) ([]byte, *structs.Response, bool) {
-	responseHash := getTCPUDPResponseHash(content)
+func XrayGetTcpUdpResponseCache(target string, ruleReq *structs.RuleRequest, tls bool, content string) ([]byte, *structs.Response, bool) {
+	responseHash := getTCPUDPResponseHash(target, ruleReq, tls, content)
 	if cache, err := GC.Get(responseHash); err == nil {
 		if requestCache, ok := cache.(*structs.TCPUDPRequestCache); ok {
+			metrics.ObserveCache("tcpudp", true)
 			return requestCache.Response, requestCache.ProtoResponse, true
 		} else {
 		}
 	}
 
+	metrics.ObserveCache("tcpudp", false)
 	return nil, nil, false
 }
@@ -0,0 +1,173 @@
+package requests
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/WAY29/pocV/pkg/xray/structs"
+	"github.com/WAY29/pocV/utils"
+)
+
+func TestMain(m *testing.M) {
+	utils.InitLog(false, false)
+	os.Exit(m.Run())
+}
+
+// 记录服务端仍未被客户端关闭的连接数
+func newCountingListener(t *testing.T) (string, *int64) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var open int64
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt64(&open, 1)
+			go func(conn net.Conn) {
+				defer conn.Close()
+				buf := make([]byte, 64)
+				for {
+					if _, err := conn.Read(buf); err != nil {
+						atomic.AddInt64(&open, -1)
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	return listener.Addr().String(), &open
+}
+
+func waitOpen(t *testing.T, open *int64, want int64) {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if atomic.LoadInt64(open) == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("open connections = %d, want %d", atomic.LoadInt64(open), want)
+}
+
+func dial(t *testing.T, addr string) net.Conn {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestConnectionSessionClose(t *testing.T) {
+	addr, open := newCountingListener(t)
+	InitCache(16)
+
+	session := NewConnectionSession(addr, "poc-a")
+	session.Set("c1", dial(t, addr))
+	session.Set("c2", dial(t, addr))
+	waitOpen(t, open, 2)
+
+	if _, ok := session.Get("c1"); !ok {
+		t.Fatal("connection c1 not found in session")
+	}
+
+	// 同一target和poc的另一次执行不共享连接
+	other := NewConnectionSession(addr, "poc-a")
+	if _, ok := other.Get("c1"); ok {
+		t.Fatal("connection c1 leaked into another session")
+	}
+
+	// 覆盖connection_id时关闭旧连接
+	session.Set("c2", dial(t, addr))
+	waitOpen(t, open, 2)
+
+	session.Close()
+	waitOpen(t, open, 0)
+
+	if _, ok := session.Get("c1"); ok {
+		t.Fatal("connection c1 still cached after session closed")
+	}
+}
+
+func TestConnectionNotEvicted(t *testing.T) {
+	addr, open := newCountingListener(t)
+	InitCache(2)
+
+	// poc执行过程中填满缓存
+	session := NewConnectionSession(addr, "poc-b")
+	session.Set("c", dial(t, addr))
+	others := make([]*ConnectionSession, 0, 5)
+	for i := 0; i < 5; i++ {
+		other := NewConnectionSession(addr, "poc-b")
+		other.Set("c", dial(t, addr))
+		others = append(others, other)
+		XraySetTcpUdpResponseCache(addr, &structs.RuleRequest{}, false, fmt.Sprintf("content%d", i), []byte("response"), nil)
+	}
+	waitOpen(t, open, 6)
+
+	// 仍在使用中的连接不会被关闭
+	conn, ok := session.Get("c")
+	if !ok {
+		t.Fatal("connection c not found in session")
+	}
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("connection c closed during session: %v", err)
+	}
+
+	session.Close()
+	waitOpen(t, open, 5)
+	for _, other := range others {
+		other.Close()
+	}
+	waitOpen(t, open, 0)
+}
+
+func TestCloseConnections(t *testing.T) {
+	addr, open := newCountingListener(t)
+	InitCache(16)
+
+	for i := 0; i < 3; i++ {
+		NewConnectionSession(addr, "poc-c").Set("c", dial(t, addr))
+	}
+	waitOpen(t, open, 3)
+
+	CloseConnections()
+	waitOpen(t, open, 0)
+}
+
+func TestTcpUdpResponseCacheKey(t *testing.T) {
+	InitCache(16)
+
+	target := "tcp://127.0.0.1:1"
+	XraySetTcpUdpResponseCache(target, &structs.RuleRequest{ReadSize: 10}, false, "ping", []byte("plain"), nil)
+	if response, _, ok := XrayGetTcpUdpResponseCache(target, &structs.RuleRequest{ReadSize: 10}, false, "ping"); !ok || string(response) != "plain" {
+		t.Fatalf("cache miss for the same request, got %q %v", response, ok)
+	}
+
+	// 内容相同但是否使用tls或读取方式不同时不命中缓存
+	tests := []struct {
+		name    string
+		ruleReq *structs.RuleRequest
+		tls     bool
+	}{
+		{"tls", &structs.RuleRequest{ReadSize: 10}, true},
+		{"read size", &structs.RuleRequest{ReadSize: 20}, false},
+		{"read until", &structs.RuleRequest{ReadSize: 10, ReadUntil: `\n`}, false},
+		{"datagrams", &structs.RuleRequest{ReadSize: 10, Datagrams: 2}, false},
+	}
+	for _, tt := range tests {
+		if _, _, ok := XrayGetTcpUdpResponseCache(target, tt.ruleReq, tt.tls, "ping"); ok {
+			t.Errorf("%s: should not hit cache of another request", tt.name)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package requests
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"golang.org/x/net/http2"
+)
+
+var (
+	Http2Client           *http.Client
+	Http2ClientNoRedirect *http.Client
+)
+
+// 强制使用http2的RoundTripper，https使用h2，http使用h2c(prior knowledge)
+type http2RoundTripper struct {
+	h2  *http2.Transport
+	h2c *http2.Transport
+}
+
+func (rt *http2RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		return rt.h2c.RoundTrip(req)
+	}
+	return rt.h2.RoundTrip(req)
+}
+
+// 初始化http2客户端，http2.Transport不支持代理，因此通过代理池自行建立连接
+func initHttp2Client() {
+	rt := &http2RoundTripper{
+		h2: &http2.Transport{
+			DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+				conn, err := Proxies.Dial(network, addr)
+				if err != nil {
+					return nil, err
+				}
+				host, _, _ := net.SplitHostPort(addr)
+				tlsConn, err := NewTLSClient(conn, host, http2.NextProtoTLS)
+				if err != nil {
+					conn.Close()
+					return nil, err
+				}
+				return tlsConn, nil
+			},
+		},
+		h2c: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return Proxies.Dial(network, addr)
+			},
+		},
+	}
+
+	Http2Client = &http.Client{
+		Transport: rt,
+		Timeout:   RequestTimeout,
+	}
+	Http2ClientNoRedirect = &http.Client{
+		Transport: rt,
+		Timeout:   RequestTimeout,
+	}
+	Http2ClientNoRedirect.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+}
+
+// 使用http2发起请求，jar为nil时不处理cookie
+func DoHttp2Request(req *http.Request, redirect bool, jar http.CookieJar) (*http.Response, int64, error) {
+	if redirect {
+		return doRequest(req, Http2Client, jar)
+	}
+	return doRequest(req, Http2ClientNoRedirect, jar)
+}
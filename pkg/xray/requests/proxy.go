@@ -0,0 +1,195 @@
+package requests
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/WAY29/pocV/internal/common/errors"
+	"golang.org/x/net/proxy"
+)
+
+// 多个上游代理时轮询使用
+type ProxyPool struct {
+	urls  []*url.URL
+	index uint64
+}
+
+var (
+	Proxies *ProxyPool
+)
+
+func init() {
+	// x/net/proxy只支持socks5，http代理使用CONNECT方法建立隧道
+	proxy.RegisterDialerType("http", newConnectDialer)
+	proxy.RegisterDialerType("https", newConnectDialer)
+}
+
+// 解析代理地址，支持http、https、socks5和socks5h，socks5可使用user:pass@host:port进行认证
+func ParseProxy(rawProxy string) (*url.URL, error) {
+	rawProxy = strings.TrimSpace(rawProxy)
+	if !strings.Contains(rawProxy, "://") {
+		rawProxy = "http://" + rawProxy
+	}
+
+	u, err := url.Parse(rawProxy)
+	if err != nil {
+		return nil, errors.Newf(errors.ProxyError, "Parse Proxy[%s] error: %v", rawProxy, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, errors.Newf(errors.ProxyError, "Unsupported proxy scheme[%s]", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.Newf(errors.ProxyError, "Proxy[%s] host can't be empty", rawProxy)
+	}
+
+	return u, nil
+}
+
+func NewProxyPool(rawProxies []string) (*ProxyPool, error) {
+	pool := &ProxyPool{
+		urls: make([]*url.URL, 0, len(rawProxies)),
+	}
+
+	for _, rawProxy := range rawProxies {
+		if strings.TrimSpace(rawProxy) == "" {
+			continue
+		}
+		u, err := ParseProxy(rawProxy)
+		if err != nil {
+			return nil, err
+		}
+		pool.urls = append(pool.urls, u)
+	}
+
+	return pool, nil
+}
+
+func (p *ProxyPool) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.urls)
+}
+
+func (p *ProxyPool) Strings() []string {
+	proxies := make([]string, 0, p.Len())
+	if p == nil {
+		return proxies
+	}
+	for _, u := range p.urls {
+		proxies = append(proxies, u.String())
+	}
+	return proxies
+}
+
+// 获取下一个代理，没有代理时返回nil
+func (p *ProxyPool) Next() *url.URL {
+	if p.Len() == 0 {
+		return nil
+	}
+	i := atomic.AddUint64(&p.index, 1) - 1
+	return p.urls[i%uint64(len(p.urls))]
+}
+
+// 用于http.Transport.Proxy
+func (p *ProxyPool) ProxyFunc(req *http.Request) (*url.URL, error) {
+	return p.Next(), nil
+}
+
+// 通过下一个代理发起连接，没有代理时直接连接
+func (p *ProxyPool) Dial(network, address string) (net.Conn, error) {
+	if Resolver == nil {
+		Resolver, _ = NewDNSResolver(nil, nil)
+	}
+	forward := Resolver
+
+	// http代理无法转发udp，x/net/proxy也不支持socks5的UDP ASSOCIATE，设置代理时不直接连接以免暴露扫描器地址
+	if p.Len() > 0 && !strings.HasPrefix(network, "tcp") {
+		return nil, errors.Newf(errors.ProxyError, "Proxy can't forward %s, refuse to connect to %s directly", network, address)
+	}
+
+	u := p.Next()
+	if u == nil {
+		return forward.Dial(network, address)
+	}
+
+	dialer, err := proxy.FromURL(u, forward)
+	if err != nil {
+		return nil, errors.Newf(errors.ProxyError, "Create dialer for proxy[%s] error: %v", u.Redacted(), err)
+	}
+
+	// 目标由代理解析，只能应用静态映射
+	return dialer.Dial(network, Resolver.StaticAddress(address))
+}
+
+// http代理的CONNECT隧道
+type connectDialer struct {
+	proxyURL *url.URL
+	forward  proxy.Dialer
+}
+
+func newConnectDialer(u *url.URL, forward proxy.Dialer) (proxy.Dialer, error) {
+	return &connectDialer{proxyURL: u, forward: forward}, nil
+}
+
+func (d *connectDialer) Dial(network, address string) (net.Conn, error) {
+	conn, err := d.forward.Dial("tcp", d.proxyURL.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.proxyURL.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: d.proxyURL.Hostname(), InsecureSkipVerify: true})
+	}
+
+	var requestBuilder strings.Builder
+	requestBuilder.WriteString(fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", address, address))
+	if user := d.proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credential := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		requestBuilder.WriteString("Proxy-Authorization: Basic " + credential + "\r\n")
+	}
+	requestBuilder.WriteString("\r\n")
+
+	conn.SetDeadline(time.Now().Add(DialTimout))
+	if _, err = conn.Write([]byte(requestBuilder.String())); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, errors.Newf(errors.ProxyError, "Proxy[%s] CONNECT %s error: %s", d.proxyURL.Redacted(), address, resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+
+	// 隧道建立后目标可能立即发送数据(如smtp banner)，这部分数据可能已被读入缓冲区
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
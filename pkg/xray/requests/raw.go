@@ -0,0 +1,238 @@
+package requests
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WAY29/pocV/internal/common/errors"
+	"github.com/WAY29/pocV/internal/common/progress"
+	"github.com/WAY29/pocV/pkg/xray/structs"
+)
+
+// 规范化原始请求: 请求头部分使用CRLF换行，去掉yaml块末尾的换行，并根据请求体更新Content-Length
+func NormalizeRawRequest(raw string) []byte {
+	var (
+		headerLines []string
+		body        string
+		hasBody     bool
+		builder     strings.Builder
+	)
+
+	lines := strings.Split(strings.TrimLeft(raw, "\r\n"), "\n")
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			body = strings.Join(lines[i+1:], "\n")
+			hasBody = true
+			break
+		}
+		headerLines = append(headerLines, line)
+	}
+	body = strings.TrimSuffix(body, "\n")
+
+	for i, line := range headerLines {
+		// 请求行不处理
+		if i != 0 && hasBody && body != "" {
+			if kv := strings.SplitN(line, ":", 2); len(kv) == 2 && strings.EqualFold(strings.TrimSpace(kv[0]), "Content-Length") {
+				continue
+			}
+		}
+		builder.WriteString(line)
+		builder.WriteString("\r\n")
+	}
+	if body != "" {
+		builder.WriteString("Content-Length: ")
+		builder.WriteString(strconv.Itoa(len(body)))
+		builder.WriteString("\r\n")
+	}
+	builder.WriteString("\r\n")
+	builder.WriteString(body)
+
+	return []byte(builder.String())
+}
+
+// 不经过net/http规范化，直接拼接原始请求，请求头按名称排序以保证每次发送的内容一致
+func BuildRawRequest(method, path, host string, header http.Header, headers map[string]string, body string) []byte {
+	var builder strings.Builder
+
+	builder.WriteString(method + " " + path + " HTTP/1.1\r\n")
+
+	// rule中的请求头优先
+	if _, ok := headers["Host"]; !ok && header.Get("Host") == "" {
+		builder.WriteString("Host: " + host + "\r\n")
+	}
+	headerKeys := make([]string, 0, len(header))
+	for k := range header {
+		headerKeys = append(headerKeys, k)
+	}
+	sort.Strings(headerKeys)
+	for _, k := range headerKeys {
+		if _, ok := headers[k]; ok {
+			continue
+		}
+		for _, v := range header[k] {
+			builder.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+	ruleHeaderKeys := make([]string, 0, len(headers))
+	for k := range headers {
+		ruleHeaderKeys = append(ruleHeaderKeys, k)
+	}
+	sort.Strings(ruleHeaderKeys)
+	hasContentLength := false
+	for _, k := range ruleHeaderKeys {
+		if strings.EqualFold(k, "Content-Length") {
+			hasContentLength = true
+		}
+		builder.WriteString(k + ": " + headers[k] + "\r\n")
+	}
+	if body != "" && !hasContentLength {
+		builder.WriteString("Content-Length: " + strconv.Itoa(len(body)) + "\r\n")
+	}
+	builder.WriteString("\r\n")
+	builder.WriteString(body)
+
+	return []byte(builder.String())
+}
+
+// 从原始请求中解析出protoRequest
+func ParseRawHttpRequest(u *url.URL, raw []byte) (*structs.Request, error) {
+	var (
+		req              = requestPool.Get().(*structs.Request)
+		rawHeaderBuilder strings.Builder
+		headers          = make(map[string]string)
+	)
+
+	req.Url = ParseUrl(u)
+	req.Raw = raw
+
+	head, body := raw, []byte(nil)
+	if i := bytes.Index(raw, []byte("\r\n\r\n")); i != -1 {
+		head, body = raw[:i], raw[i+4:]
+	}
+	req.Body = body
+
+	lines := strings.Split(string(head), "\r\n")
+	requestLine := strings.SplitN(lines[0], " ", 3)
+	if len(requestLine) < 2 {
+		PutRequest(req)
+		return nil, errors.Newf(errors.RequestError, "Invalid raw request line: %s", lines[0])
+	}
+	req.Method = requestLine[0]
+	req.Url.Path, req.Url.Query = SplitPathQuery(requestLine[1])
+
+	for _, line := range lines[1:] {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		// 重复的请求头与net/http的Header.Get一致，取第一个值，原始请求头中保留全部
+		k := strings.TrimSpace(kv[0])
+		if _, ok := headers[k]; !ok {
+			headers[k] = strings.TrimSpace(kv[1])
+		}
+		rawHeaderBuilder.WriteString(line)
+		rawHeaderBuilder.WriteString("\n")
+	}
+	req.Headers = headers
+	req.ContentType = headers["Content-Type"]
+	req.RawHeader = []byte(strings.Trim(rawHeaderBuilder.String(), "\n"))
+
+	return req, nil
+}
+
+// 通过自己建立的tcp/tls连接发送原始请求，method用于判断响应是否有响应体
+func DoRawRequest(u *url.URL, method string, raw []byte) (*http.Request, *http.Response, int64, error) {
+	var (
+		milliseconds int64
+		conn         net.Conn
+		err          error
+	)
+
+	address := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			address = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			address = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	conn, err = Proxies.Dial("tcp", address)
+	if err != nil {
+		progress.Progress.IncrementFailedRequestsBy(1)
+		return nil, nil, 0, errors.Newf(errors.RequestError, "Raw request connect to %s error: %v", address, err)
+	}
+	defer conn.Close()
+
+	if RequestTimeout > 0 {
+		conn.SetDeadline(time.Now().Add(RequestTimeout))
+	}
+
+	if u.Scheme == "https" {
+		tlsConn, err := NewTLSClient(conn, u.Hostname(), "http/1.1")
+		if err != nil {
+			progress.Progress.IncrementFailedRequestsBy(1)
+			return nil, nil, 0, errors.Newf(errors.RequestError, "Raw request tls handshake with %s error: %v", address, err)
+		}
+		conn = tlsConn
+	}
+
+	start := time.Now()
+	if _, err = conn.Write(raw); err != nil {
+		progress.Progress.IncrementFailedRequestsBy(1)
+		return nil, nil, 0, errors.Newf(errors.RequestError, "Raw request write error: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	if _, err = reader.Peek(1); err != nil {
+		progress.Progress.IncrementFailedRequestsBy(1)
+		return nil, nil, 0, errors.Newf(errors.RequestError, "Raw request read error: %v", err)
+	}
+	milliseconds = time.Since(start).Nanoseconds() / 1e6
+
+	// 仅用于记录响应对应的请求，不会被发送
+	request := &http.Request{
+		Method: method,
+		URL:    u,
+		Header: make(http.Header),
+		Host:   u.Host,
+	}
+	oResp, err := http.ReadResponse(reader, request)
+	if err != nil {
+		progress.Progress.IncrementFailedRequestsBy(1)
+		return nil, nil, 0, errors.Newf(errors.ResponseError, "Raw request parse response error: %v", err)
+	}
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		state := tlsConn.ConnectionState()
+		oResp.TLS = &state
+	}
+
+	// 连接关闭前读取完整响应体
+	var bodyReader io.Reader = oResp.Body
+	if MaxBodySize > 0 {
+		bodyReader = io.LimitReader(bodyReader, MaxBodySize)
+	}
+	body, err := ioutil.ReadAll(bodyReader)
+	oResp.Body.Close()
+	if err != nil {
+		if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+			progress.Progress.IncrementFailedRequestsBy(1)
+			return nil, nil, 0, errors.Newf(errors.ResponseError, "Raw request read body error: %v", err)
+		}
+	}
+	oResp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	progress.Progress.IncrementRequests()
+
+	return request, oResp, milliseconds, nil
+}
@@ -0,0 +1,207 @@
+package requests
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 原样记录收到的请求并返回固定响应的tcp服务
+func newRawServer(t *testing.T, response string) (string, chan []byte) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []byte, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				var raw bytes.Buffer
+				reader := bufio.NewReader(conn)
+				contentLength := 0
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					raw.WriteString(line)
+					if kv := strings.SplitN(line, ":", 2); len(kv) == 2 && strings.EqualFold(kv[0], "Content-Length") {
+						contentLength, _ = strconv.Atoi(strings.TrimSpace(kv[1]))
+					}
+					if line == "\r\n" {
+						break
+					}
+				}
+				body := make([]byte, contentLength)
+				io.ReadFull(reader, body)
+				raw.Write(body)
+
+				received <- raw.Bytes()
+				conn.Write([]byte(response))
+			}(conn)
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestNormalizeRawRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			"no body",
+			"\nGET /index.php?a=b HTTP/1.1\nHost: {{Hostname}}\nX-Test: a\n",
+			"GET /index.php?a=b HTTP/1.1\r\nHost: {{Hostname}}\r\nX-Test: a\r\n\r\n",
+		},
+		{
+			"update content-length",
+			"POST / HTTP/1.1\r\nHost: a\r\ncontent-length: 100\r\n\r\nid=1\nname=2\n",
+			"POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 11\r\n\r\nid=1\nname=2",
+		},
+		{
+			"keep content-length without body",
+			"POST / HTTP/1.1\nHost: a\nContent-Length: 0\n\n",
+			"POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 0\r\n\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := string(NormalizeRawRequest(tt.raw)); got != tt.want {
+			t.Errorf("%s: NormalizeRawRequest() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildRawRequest(t *testing.T) {
+	header := http.Header{
+		"User-Agent":      {"pocV"},
+		"X-Forwarded-For": {"127.0.0.1", "127.0.0.2"},
+		"Cookie":          {"a=1"},
+		"Accept":          {"*/*"},
+	}
+	headers := map[string]string{
+		"X-Rule":       "1",
+		"Cookie":       "b=2",
+		"Content-Type": "application/x-www-form-urlencoded",
+		"A-First":      "1",
+	}
+
+	want := "POST /a/../b?c=%zz HTTP/1.1\r\n" +
+		"Host: example.com:8080\r\n" +
+		"Accept: */*\r\n" +
+		"User-Agent: pocV\r\n" +
+		"X-Forwarded-For: 127.0.0.1\r\n" +
+		"X-Forwarded-For: 127.0.0.2\r\n" +
+		"A-First: 1\r\n" +
+		"Content-Type: application/x-www-form-urlencoded\r\n" +
+		"Cookie: b=2\r\n" +
+		"X-Rule: 1\r\n" +
+		"Content-Length: 3\r\n" +
+		"\r\n" +
+		"a=1"
+
+	// 请求头顺序固定，每次生成的请求一致
+	for i := 0; i < 20; i++ {
+		if got := string(BuildRawRequest("POST", "/a/../b?c=%zz", "example.com:8080", header, headers, "a=1")); got != want {
+			t.Fatalf("BuildRawRequest() = %q, want %q", got, want)
+		}
+	}
+
+	// 指定Host和Content-Length时不自动添加
+	got := string(BuildRawRequest("GET", "/", "example.com", nil, map[string]string{"Host": "evil.com", "Content-Length": "10"}, "a"))
+	want = "GET / HTTP/1.1\r\nContent-Length: 10\r\nHost: evil.com\r\n\r\na"
+	if got != want {
+		t.Errorf("BuildRawRequest() = %q, want %q", got, want)
+	}
+}
+
+func TestParseRawHttpRequest(t *testing.T) {
+	u, _ := url.Parse("http://example.com:8080/base?x=1")
+	raw := []byte("POST /api/../login?user=admin&p=%zz HTTP/1.1\r\nHost: example.com\r\nX-Dup: first\r\nX-Dup: second\r\nContent-Type: text/plain\r\nbroken line\r\n\r\nbody\r\n\r\ntail")
+
+	req, err := ParseRawHttpRequest(u, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer PutRequest(req)
+
+	if req.Method != "POST" || req.Url.Path != "/api/../login" || req.Url.Query != "user=admin&p=%zz" || req.Url.Host != "example.com:8080" {
+		t.Errorf("unexpected request %s %+v", req.Method, req.Url)
+	}
+	// 重复的请求头取第一个值，原始请求头保留全部
+	if req.Headers["X-Dup"] != "first" || req.ContentType != "text/plain" {
+		t.Errorf("unexpected headers %v", req.Headers)
+	}
+	if string(req.RawHeader) != "Host: example.com\nX-Dup: first\nX-Dup: second\nContent-Type: text/plain" {
+		t.Errorf("unexpected raw header %q", req.RawHeader)
+	}
+	if string(req.Body) != "body\r\n\r\ntail" || !bytes.Equal(req.Raw, raw) {
+		t.Errorf("unexpected body %q", req.Body)
+	}
+
+	if _, err := ParseRawHttpRequest(u, []byte("INVALID\r\n\r\n")); err == nil {
+		t.Error("ParseRawHttpRequest should return error for invalid request line")
+	}
+}
+
+func TestDoRawRequest(t *testing.T) {
+	address, received := newRawServer(t, "HTTP/1.1 200 OK\r\nContent-Length: 5\r\nX-Server: raw\r\n\r\nhello")
+	u, _ := url.Parse("http://" + address + "/")
+
+	// 非法路径和重复请求头原样发送
+	raw := NormalizeRawRequest("GET /a b/../%zz/<script> HTTP/1.1\nHost: " + address + "\nX-Dup: 1\nx-dup: 2\n\nbody")
+	request, response, _, err := DoRawRequest(u, "GET", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-received; !bytes.Equal(got, raw) {
+		t.Errorf("server received %q, want %q", got, raw)
+	}
+
+	body, _ := ioutil.ReadAll(response.Body)
+	if response.StatusCode != 200 || response.Header.Get("X-Server") != "raw" || string(body) != "hello" {
+		t.Errorf("unexpected response %d %v %q", response.StatusCode, response.Header, body)
+	}
+	if request.Method != "GET" || request.URL != u {
+		t.Errorf("unexpected request %+v", request)
+	}
+
+	// HEAD请求的响应没有响应体
+	address, received = newRawServer(t, "HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\n")
+	u, _ = url.Parse("http://" + address)
+	_, response, _, err = DoRawRequest(u, "HEAD", []byte("HEAD / HTTP/1.1\r\nHost: a\r\n\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-received
+	if body, _ := ioutil.ReadAll(response.Body); len(body) != 0 {
+		t.Errorf("HEAD response should not have body, got %q", body)
+	}
+
+	// 连接失败
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedAddress := listener.Addr().String()
+	listener.Close()
+	u, _ = url.Parse("http://" + closedAddress)
+	if _, _, _, err := DoRawRequest(u, "GET", raw); err == nil {
+		t.Error("DoRawRequest should return error when connection refused")
+	}
+}
@@ -2,9 +2,7 @@ package requests
 
 import (
 	"bytes"
-	"compress/gzip"
 	"crypto/tls"
-	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -18,12 +16,18 @@ import (
 	"time"
 
 	"github.com/WAY29/pocV/internal/common/errors"
+	"github.com/WAY29/pocV/internal/common/progress"
 	"github.com/WAY29/pocV/pkg/xray/structs"
+	"github.com/WAY29/pocV/utils"
+	"golang.org/x/net/http2"
 )
 
 var (
 	Client           *http.Client
 	ClientNoRedirect *http.Client
+	DisableCookie    bool
+	RequestTimeout   time.Duration
+	TLSConfig        = &tls.Config{InsecureSkipVerify: true}
 	DialTimout       = 5 * time.Second
 	KeepAlive        = 15 * time.Second
 
@@ -58,58 +62,60 @@ var (
 			return new(structs.Response)
 		},
 	}
-	httpBodyBufPool = sync.Pool{
-		New: func() interface{} {
-			return make([]byte, 1024)
-		},
-	}
-	httpBodyPool = sync.Pool{
-		New: func() interface{} {
-			return make([]byte, 4096)
-		},
-	}
 )
 
-func InitHttpClient(ThreadsNum int, DownProxy string, Timeout time.Duration) error {
-	dialer := &net.Dialer{
-		Timeout:   DialTimout,
-		KeepAlive: KeepAlive,
+func InitHttpClient(ThreadsNum int, DownProxies []string, Timeout time.Duration, disableCookie bool, enableHttp2 bool, maxBodySize int64, decodeCharset bool) error {
+	var err error
+
+	// 未初始化dns解析时使用系统解析
+	if Resolver == nil {
+		Resolver, _ = NewDNSResolver(nil, nil)
 	}
 
 	tr := &http.Transport{
-		DialContext:         dialer.DialContext,
+		DialContext:         Resolver.DialContext,
 		MaxIdleConns:        1000,
 		MaxIdleConnsPerHost: ThreadsNum * 2,
 		IdleConnTimeout:     KeepAlive,
-		TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+		TLSClientConfig:     TLSConfig,
 		TLSHandshakeTimeout: 5 * time.Second,
 	}
 
-	if DownProxy != "" {
-		u, err := url.Parse(DownProxy)
-		if err != nil {
-			wrappedErr := errors.Newf(errors.ProxyError, "Parse Proxy error: %v", err)
-			return wrappedErr
+	// 多个代理时轮询使用
+	Proxies, err = NewProxyPool(DownProxies)
+	if err != nil {
+		return err
+	}
+	if Proxies.Len() > 0 {
+		tr.Proxy = Proxies.ProxyFunc
+	}
+
+	// 自定义DialContext和TLSClientConfig会禁用http2，需要手动开启h2协商
+	if enableHttp2 {
+		tr.TLSClientConfig = TLSConfig.Clone()
+		if err = http2.ConfigureTransport(tr); err != nil {
+			return errors.Newf(errors.RequestError, "Configure http2 error: %v", err)
 		}
-		tr.Proxy = http.ProxyURL(u)
 	}
 
-	clientCookieJar, _ := cookiejar.New(nil)
-	clientNoRedirectCookieJar, _ := cookiejar.New(nil)
+	DisableCookie = disableCookie
+	RequestTimeout = Timeout
+	MaxBodySize = maxBodySize
+	DecodeCharset = decodeCharset
 
+	// cookie由每次poc执行的会话管理，见NewCookieJar
 	Client = &http.Client{
 		Transport: tr,
 		Timeout:   Timeout,
-		Jar:       clientCookieJar,
 	}
 	ClientNoRedirect = &http.Client{
 		Transport: tr,
 		Timeout:   Timeout,
-		Jar:       clientNoRedirectCookieJar,
 	}
 	ClientNoRedirect.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 		return http.ErrUseLastResponse
 	}
+	initHttp2Client()
 
 	return nil
 }
@@ -128,7 +134,61 @@ func ParseUrl(u *url.URL) *structs.UrlType {
 	return urlType
 }
 
-func DoRequest(req *http.Request, redirect bool) (*http.Response, int64, error) {
+// 根据规则覆盖请求地址的scheme、host和port，host中包含端口时同时覆盖端口
+func OverrideUrl(u *url.URL, scheme, host, port string) *url.URL {
+	newUrl := *u
+	if scheme != "" {
+		newUrl.Scheme = scheme
+	}
+
+	hostname, oPort := u.Hostname(), u.Port()
+	if host != "" {
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			hostname, oPort = h, p
+		} else {
+			hostname = strings.Trim(host, "[]")
+		}
+	}
+	if port != "" {
+		oPort = port
+	}
+	if oPort != "" {
+		newUrl.Host = net.JoinHostPort(hostname, oPort)
+	} else if strings.Contains(hostname, ":") {
+		newUrl.Host = "[" + hostname + "]"
+	} else {
+		newUrl.Host = hostname
+	}
+
+	return &newUrl
+}
+
+// 拆分规则中的path和query
+func SplitPathQuery(path string) (string, string) {
+	if i := strings.Index(path, "?"); i != -1 {
+		return path[:i], path[i+1:]
+	}
+	return path, ""
+}
+
+// 新建cookie会话，同一会话中跟随跳转和不跟随跳转的请求共享cookie，禁用cookie时返回nil
+func NewCookieJar() http.CookieJar {
+	if DisableCookie {
+		return nil
+	}
+	jar, _ := cookiejar.New(nil)
+	return jar
+}
+
+// 发起请求，jar为nil时不处理cookie
+func DoRequest(req *http.Request, redirect bool, jar http.CookieJar) (*http.Response, int64, error) {
+	if redirect {
+		return doRequest(req, Client, jar)
+	}
+	return doRequest(req, ClientNoRedirect, jar)
+}
+
+func doRequest(req *http.Request, client *http.Client, jar http.CookieJar) (*http.Response, int64, error) {
 	var (
 		milliseconds int64
 		oResp        *http.Response
@@ -149,16 +209,20 @@ func DoRequest(req *http.Request, redirect bool) (*http.Response, int64, error)
 
 	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
 
-	if redirect {
-		oResp, err = Client.Do(req)
-	} else {
-		oResp, err = ClientNoRedirect.Do(req)
+	if jar != nil {
+		sessionClient := *client
+		sessionClient.Jar = jar
+		client = &sessionClient
 	}
 
+	oResp, err = client.Do(req)
+
 	if err != nil {
+		progress.Progress.IncrementFailedRequestsBy(1)
 		wrappedErr := errors.Newf(errors.RequestError, "Request error: %v", err)
 		return nil, 0, wrappedErr
 	}
+	progress.Progress.IncrementRequests()
 
 	return oResp, milliseconds, nil
 }
@@ -201,6 +265,8 @@ func ParseHttpResponse(oResp *http.Response, milliseconds int64) (*structs.Respo
 
 	headers := make(map[string]string)
 	resp.Status = int32(oResp.StatusCode)
+	resp.Protocol = oResp.Proto
+	resp.Tls = ParseTLSState(oResp.TLS)
 	resp.Url = ParseUrl(oResp.Request.URL)
 
 	for k := range oResp.Header {
@@ -214,21 +280,31 @@ func ParseHttpResponse(oResp *http.Response, milliseconds int64) (*structs.Respo
 	}
 	resp.Headers = headers
 	resp.ContentType = oResp.Header.Get("Content-Type")
+	resp.Cookies, resp.SetCookie = ParseSetCookie(oResp.Header)
+	resp.Location = ParseLocation(oResp)
 	// 原始请求头
 	resp.RawHeader = []byte(strings.Trim(rawHeaderBuilder.String(), "\n"))
 
-	// 原始http响应
-	resp.Raw, err = httputil.DumpResponse(oResp, true)
-	body, err := GetRespBody(oResp)
+	// 读取原始响应体后再生成原始http响应，避免重复读取
+	rawBody, err := ReadRawBody(oResp)
 	if err != nil {
 		return nil, err
 	}
+	oResp.Body = ioutil.NopCloser(bytes.NewReader(rawBody))
 
+	// 原始http响应
+	resp.Raw, err = httputil.DumpResponse(oResp, true)
 	if err != nil {
-		resp.Raw = body
+		resp.Raw = rawBody
 	}
+
 	// http响应体
-	resp.Body = body
+	resp.Body, err = DecodeBody(rawBody, oResp.Header.Get("Content-Encoding"))
+	if err != nil {
+		utils.DebugF("Decode response body error: %v", err)
+		resp.Body = rawBody
+	}
+	resp.Utf8Body, resp.Charset = TranscodeBody(resp.Body, resp.ContentType)
 
 	// 响应时间
 	resp.Latency = milliseconds
@@ -260,6 +336,15 @@ func ParseTCPUDPResponse(content []byte, socket *net.Conn, transport string) (*s
 
 	resp.Raw = content
 
+	// tls连接的握手和证书信息
+	if pushbackConn, ok := connection.(*PushbackConn); ok {
+		connection = pushbackConn.Conn
+	}
+	if tlsConn, ok := connection.(*tls.Conn); ok {
+		state := tlsConn.ConnectionState()
+		resp.Tls = ParseTLSState(&state)
+	}
+
 	// source
 	addr = connection.LocalAddr().String()
 	addrList = strings.SplitN(addr, ":", 2)
@@ -288,7 +373,6 @@ func ParseTCPUDPResponse(content []byte, socket *net.Conn, transport string) (*s
 	addrType.Transport = transport
 	addrType.Addr = addr
 	addrType.Port = port
-	conn.Source = addrType
 	conn.Destination = addrType
 
 	resp.Conn = conn
@@ -296,36 +380,6 @@ func ParseTCPUDPResponse(content []byte, socket *net.Conn, transport string) (*s
 	return resp, nil
 }
 
-func GetRespBody(oResp *http.Response) ([]byte, error) {
-	body := httpBodyPool.Get().([]byte)
-	defer httpBodyPool.Put(body)
-
-	if oResp.Header.Get("Content-Encoding") == "gzip" {
-		gr, _ := gzip.NewReader(oResp.Body)
-		defer gr.Close()
-		for {
-			buf := httpBodyBufPool.Get().([]byte)
-			n, err := gr.Read(buf)
-			if err != nil && err != io.EOF {
-				return nil, err
-			}
-			if n == 0 {
-				break
-			}
-			body = append(body, buf...)
-			httpBodyBufPool.Put(buf)
-		}
-	} else {
-		raw, err := ioutil.ReadAll(oResp.Body)
-		if err != nil {
-			wrappedErr := errors.Newf(errors.ResponseError, "Get response body error: %v", err)
-			return nil, wrappedErr
-		}
-		body = raw
-	}
-	return body, nil
-}
-
 func PutUrlType(urlType *structs.UrlType) {
 	urlType.Scheme = ""
 	urlType.Domain = ""
@@ -376,6 +430,15 @@ func PutResponse(response *structs.Response) {
 	response.RawHeader = nil
 	response.Latency = 0
 	response.Conn = nil
+	response.Protocol = ""
+	response.Utf8Body = nil
+	response.Charset = ""
+	response.Tls = nil
+	response.Title = ""
+	response.BodyString = ""
+	response.Cookies = nil
+	response.SetCookie = nil
+	response.Location = ""
 
 	responsePool.Put(response)
 }
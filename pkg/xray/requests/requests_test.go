@@ -0,0 +1,47 @@
+package requests
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestOverrideUrl(t *testing.T) {
+	u, _ := url.Parse("http://example.com:8080/admin?id=1")
+	tests := []struct {
+		scheme, host, port string
+		want               string
+	}{
+		{"", "", "", "http://example.com:8080/admin?id=1"},
+		{"https", "", "", "https://example.com:8080/admin?id=1"},
+		{"", "", "9090", "http://example.com:9090/admin?id=1"},
+		{"", "127.0.0.1", "", "http://127.0.0.1:8080/admin?id=1"},
+		{"", "127.0.0.1:7001", "", "http://127.0.0.1:7001/admin?id=1"},
+		{"", "127.0.0.1:7001", "7002", "http://127.0.0.1:7002/admin?id=1"},
+		{"", "::1", "", "http://[::1]:8080/admin?id=1"},
+	}
+
+	for _, tt := range tests {
+		if got := OverrideUrl(u, tt.scheme, tt.host, tt.port).String(); got != tt.want {
+			t.Errorf("OverrideUrl(%q, %q, %q) = %s, want %s", tt.scheme, tt.host, tt.port, got, tt.want)
+		}
+	}
+	if u.String() != "http://example.com:8080/admin?id=1" {
+		t.Errorf("OverrideUrl should not modify original url: %s", u)
+	}
+
+	u, _ = url.Parse("http://example.com/")
+	if got := OverrideUrl(u, "", "[::1]", "").String(); got != "http://[::1]/" {
+		t.Errorf("OverrideUrl ipv6 host = %s", got)
+	}
+}
+
+func TestSplitPathQuery(t *testing.T) {
+	path, query := SplitPathQuery("/index.php?s=/index/think&a=b?c")
+	if path != "/index.php" || query != "s=/index/think&a=b?c" {
+		t.Errorf("unexpected path %q and query %q", path, query)
+	}
+	path, query = SplitPathQuery("/index.php")
+	if path != "/index.php" || query != "" {
+		t.Errorf("unexpected path %q and query %q", path, query)
+	}
+}
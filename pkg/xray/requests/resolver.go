@@ -0,0 +1,152 @@
+package requests
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/WAY29/pocV/internal/common/errors"
+)
+
+// 带静态映射和缓存的dns解析，用于http、tcp和udp连接
+type DNSResolver struct {
+	hosts       map[string]string
+	nameservers []string
+	index       uint64
+	resolver    *net.Resolver
+	dialer      *net.Dialer
+
+	cache sync.Map
+}
+
+var (
+	Resolver *DNSResolver
+)
+
+// 初始化dns解析，resolves为host:ip形式的静态映射，nameservers为自定义dns服务器
+func InitResolver(resolves []string, nameservers []string) error {
+	var err error
+	Resolver, err = NewDNSResolver(resolves, nameservers)
+	return err
+}
+
+func NewDNSResolver(resolves []string, nameservers []string) (*DNSResolver, error) {
+	r := &DNSResolver{
+		hosts:       make(map[string]string, len(resolves)),
+		nameservers: make([]string, 0, len(nameservers)),
+		resolver:    net.DefaultResolver,
+		dialer: &net.Dialer{
+			Timeout:   DialTimout,
+			KeepAlive: KeepAlive,
+		},
+	}
+
+	for _, resolve := range resolves {
+		kv := strings.SplitN(strings.TrimSpace(resolve), ":", 2)
+		if len(kv) != 2 || kv[0] == "" || net.ParseIP(kv[1]) == nil {
+			return nil, errors.Newf(errors.RequestError, "Invalid resolve[%s], should be host:ip", resolve)
+		}
+		r.hosts[strings.ToLower(kv[0])] = kv[1]
+	}
+
+	for _, nameserver := range nameservers {
+		nameserver = strings.TrimSpace(nameserver)
+		if nameserver == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(nameserver); err != nil {
+			nameserver = net.JoinHostPort(nameserver, "53")
+		}
+		r.nameservers = append(r.nameservers, nameserver)
+	}
+
+	if len(r.nameservers) > 0 {
+		r.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return r.dialer.DialContext(ctx, network, r.nextNameserver())
+			},
+		}
+	}
+
+	return r, nil
+}
+
+func (r *DNSResolver) Hosts() map[string]string {
+	return r.hosts
+}
+
+func (r *DNSResolver) Nameservers() []string {
+	return r.nameservers
+}
+
+// 多个dns服务器时轮询使用
+func (r *DNSResolver) nextNameserver() string {
+	i := atomic.AddUint64(&r.index, 1) - 1
+	return r.nameservers[i%uint64(len(r.nameservers))]
+}
+
+// 解析域名，优先使用静态映射，解析结果在本次运行中缓存
+func (r *DNSResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	host = strings.ToLower(host)
+
+	if ip, ok := r.hosts[host]; ok {
+		return []string{ip}, nil
+	}
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		return []string{ip.String()}, nil
+	}
+	if ips, ok := r.cache.Load(host); ok {
+		return ips.([]string), nil
+	}
+
+	ips, err := r.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.Store(host, ips)
+
+	return ips, nil
+}
+
+// 将地址中的域名替换为静态映射的ip，没有映射时原样返回
+func (r *DNSResolver) StaticAddress(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	if ip, ok := r.hosts[strings.ToLower(host)]; ok {
+		return net.JoinHostPort(ip, port)
+	}
+	return address
+}
+
+// 解析后依次尝试连接每个ip
+func (r *DNSResolver) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := r.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	for _, ip := range ips {
+		conn, err = r.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+
+	return nil, err
+}
+
+// 用于x/net/proxy
+func (r *DNSResolver) Dial(network, address string) (net.Conn, error) {
+	return r.DialContext(context.Background(), network, address)
+}
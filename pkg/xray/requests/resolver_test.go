@@ -0,0 +1,123 @@
+package requests
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/WAY29/pocV/internal/common/testutils"
+)
+
+func TestNewDNSResolver(t *testing.T) {
+	for _, resolve := range []string{"example.com", "example.com:", ":127.0.0.1", "example.com:localhost"} {
+		if _, err := NewDNSResolver([]string{resolve}, nil); err == nil {
+			t.Errorf("NewDNSResolver(%q) should return error", resolve)
+		}
+	}
+
+	r, err := NewDNSResolver([]string{" Example.COM:127.0.0.1 ", "v6.example.com:::1"}, []string{"10.0.0.1", "", "10.0.0.2:5353", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Hosts()["example.com"] != "127.0.0.1" || r.Hosts()["v6.example.com"] != "::1" {
+		t.Errorf("unexpected hosts %v", r.Hosts())
+	}
+	// 未指定端口时使用53
+	want := []string{"10.0.0.1:53", "10.0.0.2:5353", "[::1]:53"}
+	if got := r.Nameservers(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("Nameservers() = %v, want %v", got, want)
+	}
+
+	// 没有自定义dns服务器时使用系统解析
+	r, _ = NewDNSResolver(nil, []string{""})
+	if r.resolver != net.DefaultResolver {
+		t.Error("resolver should fall back to net.DefaultResolver without nameservers")
+	}
+}
+
+func TestDNSResolverHosts(t *testing.T) {
+	nameserver, queries := testutils.NewDNSServer(t, "10.0.0.9")
+	r, err := NewDNSResolver([]string{"static.pocv.test:10.0.0.8"}, []string{nameserver})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 静态映射优先于dns服务器，且不区分大小写
+	ips, err := r.LookupHost(context.Background(), "STATIC.pocv.test")
+	if err != nil || len(ips) != 1 || ips[0] != "10.0.0.8" {
+		t.Errorf("LookupHost static got %v, %v", ips, err)
+	}
+	ips, err = r.LookupHost(context.Background(), "[::1]")
+	if err != nil || len(ips) != 1 || ips[0] != "::1" {
+		t.Errorf("LookupHost ip got %v, %v", ips, err)
+	}
+	if n := atomic.LoadInt64(queries); n != 0 {
+		t.Errorf("static hosts and ips should not be queried, got %d queries", n)
+	}
+
+	if got := r.StaticAddress("static.pocv.test:80"); got != "10.0.0.8:80" {
+		t.Errorf("StaticAddress static got %s", got)
+	}
+	if got := r.StaticAddress("other.pocv.test:80"); got != "other.pocv.test:80" {
+		t.Errorf("StaticAddress other got %s", got)
+	}
+
+	// 通过静态映射连接
+	target := newBannerServer(t)
+	_, port, _ := net.SplitHostPort(target)
+	r, _ = NewDNSResolver([]string{"static.pocv.test:127.0.0.1"}, nil)
+	conn, err := r.Dial("tcp", net.JoinHostPort("static.pocv.test", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
+
+func TestDNSResolverNameservers(t *testing.T) {
+	nameserver1, queries1 := testutils.NewDNSServer(t, "10.0.0.1")
+	nameserver2, queries2 := testutils.NewDNSServer(t, "10.0.0.1")
+	r, err := NewDNSResolver(nil, []string{nameserver1, nameserver2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, host := range []string{"a.pocv.test", "b.pocv.test", "c.pocv.test"} {
+		ips, err := r.LookupHost(context.Background(), host)
+		if err != nil || len(ips) != 1 || ips[0] != "10.0.0.1" {
+			t.Errorf("LookupHost(%s) got %v, %v", host, ips, err)
+		}
+	}
+	// 多个dns服务器轮询使用
+	if atomic.LoadInt64(queries1) == 0 || atomic.LoadInt64(queries2) == 0 {
+		t.Errorf("nameservers should be used in turn, got %d and %d queries", atomic.LoadInt64(queries1), atomic.LoadInt64(queries2))
+	}
+}
+
+func TestDNSResolverCache(t *testing.T) {
+	nameserver, queries := testutils.NewDNSServer(t, "10.0.0.9")
+	r, err := NewDNSResolver(nil, []string{nameserver})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ips, err := r.LookupHost(context.Background(), "cache.pocv.test")
+	if err != nil || len(ips) != 1 || ips[0] != "10.0.0.9" {
+		t.Fatalf("LookupHost got %v, %v", ips, err)
+	}
+	n := atomic.LoadInt64(queries)
+	if n == 0 {
+		t.Fatal("nameserver was not queried")
+	}
+
+	// 本次运行中缓存解析结果
+	for i := 0; i < 3; i++ {
+		ips, err = r.LookupHost(context.Background(), "Cache.pocv.test")
+		if err != nil || len(ips) != 1 || ips[0] != "10.0.0.9" {
+			t.Errorf("cached LookupHost got %v, %v", ips, err)
+		}
+	}
+	if got := atomic.LoadInt64(queries); got != n {
+		t.Errorf("cached host should not be queried again, got %d queries, want %d", got, n)
+	}
+}
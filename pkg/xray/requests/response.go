@@ -0,0 +1,92 @@
+package requests
+
+import (
+	"html"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/WAY29/pocV/pkg/xray/structs"
+)
+
+// 按需计算的响应字段
+type ResponseFields uint8
+
+const (
+	TitleField ResponseFields = 1 << iota
+	BodyStringField
+)
+
+var (
+	titleRegexp      = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	whitespaceRegexp = regexp.MustCompile(`\s+`)
+
+	responseFieldNames = map[ResponseFields]string{
+		TitleField:      "response.title",
+		BodyStringField: "response.body_string",
+	}
+)
+
+// 根据表达式判断需要计算的响应字段
+func ParseResponseFields(expressions ...string) ResponseFields {
+	var fields ResponseFields
+
+	for _, expression := range expressions {
+		for field, name := range responseFieldNames {
+			if strings.Contains(expression, name) {
+				fields |= field
+			}
+		}
+	}
+
+	return fields
+}
+
+// 计算title、body_string等由响应体生成的字段
+func FillResponseFields(resp *structs.Response, fields ResponseFields) {
+	body := resp.Utf8Body
+	if body == nil {
+		body = resp.Body
+	}
+
+	if fields&TitleField != 0 {
+		resp.Title = ParseTitle(body)
+	}
+	if fields&BodyStringField != 0 {
+		resp.BodyString = string(body)
+	}
+}
+
+// 解析html标题
+func ParseTitle(body []byte) string {
+	match := titleRegexp.FindSubmatch(body)
+	if match == nil {
+		return ""
+	}
+	title := html.UnescapeString(string(match[1]))
+	return strings.TrimSpace(whitespaceRegexp.ReplaceAllString(title, " "))
+}
+
+// 解析Set-Cookie响应头，返回cookie名值映射和原始Set-Cookie列表
+func ParseSetCookie(header http.Header) (map[string]string, []string) {
+	setCookie := header.Values("Set-Cookie")
+	cookies := make(map[string]string, len(setCookie))
+
+	for _, cookie := range (&http.Response{Header: header}).Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+
+	return cookies, setCookie
+}
+
+// 解析Location响应头，相对地址基于请求地址转换为绝对地址
+func ParseLocation(oResp *http.Response) string {
+	location := oResp.Header.Get("Location")
+	if location == "" {
+		return ""
+	}
+	if u, err := oResp.Location(); err == nil {
+		return u.String()
+	}
+	return location
+}
@@ -0,0 +1,49 @@
+package requests
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseTitle(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"<html><head><title>pocV</title></head></html>", "pocV"},
+		{"<TITLE lang=\"en\">\n  Login &amp; Admin\n</TITLE>", "Login & Admin"},
+		{"<title></title>", ""},
+		{"no title", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ParseTitle([]byte(tt.body)); got != tt.want {
+			t.Errorf("ParseTitle(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestParseSetCookie(t *testing.T) {
+	header := http.Header{}
+	header.Add("Set-Cookie", "JSESSIONID=abc; Path=/; HttpOnly")
+	header.Add("Set-Cookie", "rememberMe=deleteMe; Max-Age=0")
+
+	cookies, setCookie := ParseSetCookie(header)
+	if cookies["JSESSIONID"] != "abc" || cookies["rememberMe"] != "deleteMe" {
+		t.Errorf("unexpected cookies: %v", cookies)
+	}
+	if len(setCookie) != 2 || setCookie[1] != "rememberMe=deleteMe; Max-Age=0" {
+		t.Errorf("unexpected set_cookie: %v", setCookie)
+	}
+}
+
+func TestParseResponseFields(t *testing.T) {
+	fields := ParseResponseFields(`response.title.contains("admin")`, `response.status == 200`)
+	if fields != TitleField {
+		t.Errorf("unexpected fields: %b", fields)
+	}
+	fields = ParseResponseFields(`response.body_string.contains("admin") && response.title == ""`)
+	if fields != TitleField|BodyStringField {
+		t.Errorf("unexpected fields: %b", fields)
+	}
+}
@@ -0,0 +1,165 @@
+package requests
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WAY29/pocV/internal/common/errors"
+)
+
+const (
+	// udp报文最大长度
+	MaxDatagramSize = 65535
+	// udp未收到响应时默认的重传次数
+	DefaultUDPRetries = 2
+)
+
+var udpBufPool = sync.Pool{
+	New: func() interface{} {
+		return make([]byte, MaxDatagramSize)
+	},
+}
+
+// 解码tcp/udp请求内容，encoding支持hex/base64，为空时原样发送
+func DecodeContent(content, encoding string) ([]byte, error) {
+	switch strings.ToLower(encoding) {
+	case "":
+		return []byte(content), nil
+	case "hex":
+		// 忽略空白字符和\x前缀
+		content = strings.ReplaceAll(content, `\x`, "")
+		content = strings.Join(strings.Fields(content), "")
+		data, err := hex.DecodeString(content)
+		if err != nil {
+			return nil, errors.Newf(errors.RequestError, "Decode hex content error: %v", err)
+		}
+		return data, nil
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(content), ""))
+		if err != nil {
+			return nil, errors.Newf(errors.RequestError, "Decode base64 content error: %v", err)
+		}
+		return data, nil
+	default:
+		return nil, errors.Newf(errors.RequestError, "Unsupported content_encoding: %s", encoding)
+	}
+}
+
+// 可以退回多读数据的连接，同一connection_id的后续rule会先读取退回的数据
+type PushbackConn struct {
+	net.Conn
+	pending []byte
+}
+
+func NewPushbackConn(conn net.Conn) *PushbackConn {
+	return &PushbackConn{Conn: conn}
+}
+
+func (c *PushbackConn) Read(b []byte) (int, error) {
+	if len(c.pending) > 0 {
+		n := copy(b, c.pending)
+		c.pending = c.pending[n:]
+		return n, nil
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *PushbackConn) Unread(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	c.pending = append(append([]byte{}, b...), c.pending...)
+}
+
+// 读取响应，直到EOF、超时、读取到readSize字节或匹配readUntil，readSize为0且readUntil为nil时读取到EOF或超时
+// 多读的数据会退回PushbackConn
+func ReadTCPUDP(conn net.Conn, buffer []byte, readSize int, readUntil *regexp.Regexp) ([]byte, error) {
+	response := make([]byte, 0, len(buffer))
+
+	unread := func(b []byte) {
+		if pushbackConn, ok := conn.(*PushbackConn); ok {
+			pushbackConn.Unread(b)
+		}
+	}
+
+	for {
+		n, err := conn.Read(buffer)
+		response = append(response, buffer[:n]...)
+
+		if readSize > 0 && len(response) >= readSize {
+			unread(response[readSize:])
+			return response[:readSize], nil
+		}
+		if readUntil != nil {
+			if loc := readUntil.FindIndex(response); loc != nil {
+				unread(response[loc[1]:])
+				return response[:loc[1]], nil
+			}
+		}
+
+		if err != nil {
+			if err == io.EOF {
+			} else if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			} else {
+				return response, err
+			}
+			return response, nil
+		}
+	}
+}
+
+// 发送udp报文并按报文读取响应，收到datagrams个报文后立即返回
+// timeout平均分配给每次发送，未收到任何响应时重传，最多重传retries次
+func ReadUDP(conn net.Conn, content []byte, datagrams, retries int, timeout time.Duration) ([]byte, error) {
+	var (
+		buffer   = udpBufPool.Get().([]byte)
+		response []byte
+		received int
+	)
+	defer udpBufPool.Put(buffer)
+
+	if datagrams <= 0 {
+		datagrams = 1
+	}
+	// 没有发送内容时无需重传
+	if retries < 0 || len(content) == 0 {
+		retries = 0
+	}
+	interval := timeout / time.Duration(retries+1)
+
+	for attempt := 0; attempt <= retries && received == 0; attempt++ {
+		if len(content) > 0 {
+			if _, err := conn.Write(content); err != nil {
+				return nil, err
+			}
+		}
+
+		deadline := time.Now().Add(interval)
+		for received < datagrams {
+			// 收到第一个报文后使用剩余的全部时间等待后续报文
+			if received == 0 {
+				conn.SetReadDeadline(deadline)
+			}
+			n, err := conn.Read(buffer)
+			if err != nil {
+				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					break
+				}
+				return response, err
+			}
+			response = append(response, buffer[:n]...)
+			received++
+			if received == 1 {
+				conn.SetReadDeadline(time.Now().Add(timeout - interval*time.Duration(attempt)))
+			}
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,282 @@
+package requests
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDecodeContent(t *testing.T) {
+	tests := []struct {
+		content  string
+		encoding string
+		want     []byte
+		wantErr  bool
+	}{
+		{"PING\r\n", "", []byte("PING\r\n"), false},
+		{"0d0a 00ff", "hex", []byte{0x0d, 0x0a, 0x00, 0xff}, false},
+		{"\\x00\\x01\n\\x02", "HEX", []byte{0x00, 0x01, 0x02}, false},
+		{"0g", "hex", nil, true},
+		{"abc", "hex", nil, true},
+		{"UElO\nRw==", "base64", []byte("PING"), false},
+		{"UElO=", "base64", nil, true},
+		{"PING", "gzip", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := DecodeContent(tt.content, tt.encoding)
+		if (err != nil) != tt.wantErr || !bytes.Equal(got, tt.want) {
+			t.Errorf("DecodeContent(%q, %q) = %q, %v, want %q, wantErr %v", tt.content, tt.encoding, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPushbackConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := NewPushbackConn(client)
+	defer conn.Close()
+
+	go server.Write([]byte("cd"))
+
+	// 后退回的数据先读取
+	conn.Unread([]byte("b"))
+	conn.Unread([]byte("a"))
+	conn.Unread(nil)
+
+	buf := make([]byte, 1)
+	got := make([]byte, 0, 4)
+	for len(got) < 4 {
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, buf[:n]...)
+	}
+	if string(got) != "abcd" {
+		t.Errorf("read %q, want abcd", got)
+	}
+}
+
+// 服务端按chunks依次写入后关闭或保持连接
+func newPipe(t *testing.T, chunks []string, closeAfter bool) net.Conn {
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	go func() {
+		for _, chunk := range chunks {
+			if _, err := server.Write([]byte(chunk)); err != nil {
+				return
+			}
+		}
+		if closeAfter {
+			server.Close()
+		}
+	}()
+
+	return client
+}
+
+func TestReadTCPUDP(t *testing.T) {
+	tests := []struct {
+		name       string
+		chunks     []string
+		closeAfter bool
+		readSize   int
+		readUntil  string
+		want       string
+	}{
+		{"eof", []string{"hello ", "world"}, true, 0, "", "hello world"},
+		{"timeout", []string{"hello"}, false, 0, "", "hello"},
+		{"read size", []string{"hel", "lo world"}, false, 5, "", "hello"},
+		{"read size larger than response", []string{"hello"}, true, 10, "", "hello"},
+		{"read until across chunks", []string{"220 ban", "ner\r", "\n250 ok"}, false, 0, `\r\n`, "220 banner\r\n"},
+		{"read until regexp", []string{"SSH-2.0-OpenSSH_8.2\r\n"}, false, 0, `SSH-[\d.]+-\S+\r\n`, "SSH-2.0-OpenSSH_8.2\r\n"},
+		{"read until not matched", []string{"hello"}, true, 0, `\n`, "hello"},
+		{"read size before read until", []string{"abcdef\n"}, false, 3, `\n`, "abc"},
+	}
+
+	for _, tt := range tests {
+		conn := newPipe(t, tt.chunks, tt.closeAfter)
+		conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+
+		var readUntil *regexp.Regexp
+		if tt.readUntil != "" {
+			readUntil = regexp.MustCompile(tt.readUntil)
+		}
+		got, err := ReadTCPUDP(conn, make([]byte, 4), tt.readSize, readUntil)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("%s: ReadTCPUDP() = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	// 非EOF和超时的错误
+	conn := newPipe(t, nil, false)
+	conn.Close()
+	if _, err := ReadTCPUDP(conn, make([]byte, 4), 0, nil); err != io.ErrClosedPipe {
+		t.Errorf("ReadTCPUDP() on closed conn error = %v, want %v", err, io.ErrClosedPipe)
+	}
+}
+
+func TestReadTCPUDPPushback(t *testing.T) {
+	conn := NewPushbackConn(newPipe(t, []string{"220 banner\r\n250 ", "ok\r\n", "tail"}, false))
+	buffer := make([]byte, 64)
+
+	// 多读的数据退回连接，下一次读取先返回这部分数据
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	got, err := ReadTCPUDP(conn, buffer, 0, regexp.MustCompile(`\r\n`))
+	if err != nil || string(got) != "220 banner\r\n" {
+		t.Fatalf("first read = %q, %v", got, err)
+	}
+	got, err = ReadTCPUDP(conn, buffer, 0, regexp.MustCompile(`\r\n`))
+	if err != nil || string(got) != "250 ok\r\n" {
+		t.Fatalf("second read = %q, %v", got, err)
+	}
+	got, err = ReadTCPUDP(conn, buffer, 2, nil)
+	if err != nil || string(got) != "ta" {
+		t.Fatalf("third read = %q, %v", got, err)
+	}
+	got, err = ReadTCPUDP(conn, buffer, 0, nil)
+	if err != nil || string(got) != "il" {
+		t.Fatalf("fourth read = %q, %v", got, err)
+	}
+}
+
+func TestReadTCPUDPConnectionReuse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// banner和第一个命令的响应一次性发送
+		conn.Write([]byte("220 ready\r\n"))
+		buf := make([]byte, 64)
+		n, _ := conn.Read(buf)
+		conn.Write([]byte("echo " + string(buf[:n]) + "\r\nbye\r\n"))
+		conn.Read(buf)
+	}()
+
+	InitCache(16)
+	session := NewConnectionSession(listener.Addr().String(), "poc-reuse")
+	defer session.Close()
+	session.Set("c", NewPushbackConn(dial(t, listener.Addr().String())))
+
+	read := func(write string) string {
+		conn, ok := session.Get("c")
+		if !ok {
+			t.Fatal("connection c not found in session")
+		}
+		if write != "" {
+			conn.Write([]byte(write))
+		}
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		got, err := ReadTCPUDP(conn, make([]byte, 1024), 0, regexp.MustCompile(`\r\n`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(got)
+	}
+
+	if got := read(""); got != "220 ready\r\n" {
+		t.Errorf("banner = %q", got)
+	}
+	if got := read("hi"); got != "echo hi\r\n" {
+		t.Errorf("echo = %q", got)
+	}
+	// 同一connection_id的后续rule读取到上一次多读的数据
+	if got := read(""); got != "bye\r\n" {
+		t.Errorf("pushback = %q", got)
+	}
+}
+
+// udp服务端丢弃前drop个报文，之后每收到一个报文回复responses，返回收到的报文数
+func newUDPServer(t *testing.T, drop int64, responses ...[]byte) (net.Conn, *int64) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	var received int64
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			_, addr, err := server.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if atomic.AddInt64(&received, 1) <= drop {
+				continue
+			}
+			for _, response := range responses {
+				server.WriteTo(response, addr)
+			}
+		}
+	}()
+
+	conn, err := net.Dial("udp", server.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, &received
+}
+
+func TestReadUDP(t *testing.T) {
+	large := bytes.Repeat([]byte("x"), 4096)
+	tests := []struct {
+		name         string
+		drop         int64
+		responses    [][]byte
+		content      string
+		datagrams    int
+		retries      int
+		want         string
+		wantReceived int64
+		early        bool
+	}{
+		{"single datagram", 0, [][]byte{[]byte("pong")}, "ping", 1, 2, "pong", 1, true},
+		{"retransmission", 1, [][]byte{[]byte("pong")}, "ping", 1, 2, "pong", 2, true},
+		{"retransmission exhausted", 5, [][]byte{[]byte("pong")}, "ping", 1, 2, "", 3, false},
+		{"large datagram", 0, [][]byte{large}, "ping", 1, 0, string(large), 1, true},
+		{"multiple datagrams", 0, [][]byte{[]byte("a"), []byte("b"), []byte("c")}, "ping", 2, 2, "ab", 1, true},
+		// 收到响应后不再重传，等待剩余时间
+		{"fewer datagrams", 0, [][]byte{[]byte("a")}, "ping", 2, 2, "a", 1, false},
+	}
+
+	timeout := 600 * time.Millisecond
+	for _, tt := range tests {
+		conn, received := newUDPServer(t, tt.drop, tt.responses...)
+
+		start := time.Now()
+		got, err := ReadUDP(conn, []byte(tt.content), tt.datagrams, tt.retries, timeout)
+		elapsed := time.Since(start)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("%s: ReadUDP() = %d bytes, %v, want %d bytes", tt.name, len(got), err, len(tt.want))
+		}
+		if tt.early && elapsed >= timeout/2 {
+			t.Errorf("%s: ReadUDP() returned after %v, want early return", tt.name, elapsed)
+		}
+		if !tt.early && elapsed < timeout*3/4 {
+			t.Errorf("%s: ReadUDP() returned after %v, want wait for timeout %v", tt.name, elapsed, timeout)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		if got := atomic.LoadInt64(received); got != tt.wantReceived {
+			t.Errorf("%s: server received %d datagrams, want %d", tt.name, got, tt.wantReceived)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package requests
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/WAY29/pocV/internal/common/errors"
+	"github.com/WAY29/pocV/pkg/xray/structs"
+)
+
+var tlsVersionNames = map[uint16]string{
+	tls.VersionSSL30: "SSLv3",
+	tls.VersionTLS10: "TLS1.0",
+	tls.VersionTLS11: "TLS1.1",
+	tls.VersionTLS12: "TLS1.2",
+	tls.VersionTLS13: "TLS1.3",
+}
+
+// 初始化tls配置，versions为1.0/1.1/1.2/1.3，为空时使用默认值
+func InitTLSConfig(certFile, keyFile, minVersion, maxVersion string, ciphers []string, serverName string) error {
+	var err error
+
+	config := &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         serverName,
+	}
+
+	// 客户端证书
+	if certFile != "" || keyFile != "" {
+		if keyFile == "" {
+			keyFile = certFile
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return errors.Newf(errors.FileError, "Load client certificate error: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	// tls版本
+	if config.MinVersion, err = parseTLSVersion(minVersion); err != nil {
+		return err
+	}
+	if config.MaxVersion, err = parseTLSVersion(maxVersion); err != nil {
+		return err
+	}
+	if config.MinVersion != 0 && config.MaxVersion != 0 && config.MinVersion > config.MaxVersion {
+		return errors.Newf(errors.ConfigError, "TLS min version %s is greater than max version %s", minVersion, maxVersion)
+	}
+
+	// 加密套件，tls1.3的加密套件不可配置
+	if len(ciphers) > 0 {
+		if config.CipherSuites, err = parseCipherSuites(ciphers); err != nil {
+			return err
+		}
+	}
+
+	TLSConfig = config
+	return nil
+}
+
+func parseTLSVersion(version string) (uint16, error) {
+	version = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "tls")
+	if version == "" {
+		return 0, nil
+	}
+	for v, name := range tlsVersionNames {
+		if v != tls.VersionSSL30 && strings.TrimPrefix(strings.ToLower(name), "tls") == version {
+			return v, nil
+		}
+	}
+	return 0, errors.Newf(errors.ConfigError, "Invalid TLS version: %s", version)
+}
+
+func parseCipherSuites(names []string) ([]uint16, error) {
+	suites := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		suites[suite.Name] = suite.ID
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		suites[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, ok := suites[strings.ToUpper(name)]
+		if !ok {
+			return nil, errors.Newf(errors.ConfigError, "Invalid cipher suite: %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// 在已建立的连接上进行tls握手，未指定SNI时使用serverName
+func NewTLSClient(conn net.Conn, serverName string, nextProtos ...string) (*tls.Conn, error) {
+	config := TLSConfig.Clone()
+	if config.ServerName == "" {
+		config.ServerName = serverName
+	}
+	if len(nextProtos) > 0 {
+		config.NextProtos = nextProtos
+	}
+
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+func TLSVersionName(version uint16) string {
+	if name, ok := tlsVersionNames[version]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+// 解析tls握手信息，state为nil时返回nil
+func ParseTLSState(state *tls.ConnectionState) *structs.TlsInfoType {
+	if state == nil {
+		return nil
+	}
+
+	info := &structs.TlsInfoType{
+		Version:            TLSVersionName(state.Version),
+		Cipher:             tls.CipherSuiteName(state.CipherSuite),
+		ServerName:         state.ServerName,
+		NegotiatedProtocol: state.NegotiatedProtocol,
+		Ja3S:               approximateJA3S(state),
+	}
+	if len(state.PeerCertificates) > 0 {
+		info.Certificate = ParseCertificate(state.PeerCertificates[0])
+	}
+
+	return info
+}
+
+func ParseCertificate(cert *x509.Certificate) *structs.CertificateType {
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.IPAddresses))
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	return &structs.CertificateType{
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		CommonName:  cert.Subject.CommonName,
+		Sans:        sans,
+		Serial:      strings.ToLower(cert.SerialNumber.Text(16)),
+		NotBefore:   cert.NotBefore.Unix(),
+		NotAfter:    cert.NotAfter.Unix(),
+		Expired:     time.Now().After(cert.NotAfter),
+		SelfSigned:  isSelfSigned(cert),
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+	}
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	if cert.Subject.String() != cert.Issuer.String() {
+		return false
+	}
+	// CheckSignatureFrom要求签发者为CA，自签名的叶子证书通常不是CA，直接校验签名
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// 标准库无法获取ServerHello扩展，使用协商的版本、加密套件和ALPN计算近似的JA3S指纹，与标准JA3S不同
+func approximateJA3S(state *tls.ConnectionState) string {
+	sum := md5.Sum([]byte(fmt.Sprintf("%d,%d,%s", state.Version, state.CipherSuite, state.NegotiatedProtocol)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,212 @@
+package requests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 生成自签名证书，返回证书和私钥的PEM
+func newCertificatePEM(t *testing.T, commonName string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    uint16
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"1.0", tls.VersionTLS10, false},
+		{"1.1", tls.VersionTLS11, false},
+		{" TLS1.2 ", tls.VersionTLS12, false},
+		{"tls1.3", tls.VersionTLS13, false},
+		{"1.4", 0, true},
+		{"sslv3", 0, true},
+		{"v3", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTLSVersion(tt.version)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseTLSVersion(%q) = %d, %v, want %d, wantErr %v", tt.version, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	got, err := parseCipherSuites([]string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", " tls_rsa_with_aes_128_cbc_sha ", "", "TLS_RSA_WITH_RC4_128_SHA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 支持不安全的加密套件，忽略空值
+	want := []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_RSA_WITH_AES_128_CBC_SHA, tls.TLS_RSA_WITH_RC4_128_SHA}
+	if len(got) != len(want) {
+		t.Fatalf("parseCipherSuites() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("parseCipherSuites()[%d] = %d, want %d", i, got[i], want[i])
+		}
+	}
+
+	if _, err := parseCipherSuites([]string{"TLS_NOT_EXIST"}); err == nil {
+		t.Error("parseCipherSuites should return error for invalid cipher suite")
+	}
+}
+
+func TestInitTLSConfig(t *testing.T) {
+	oldTLSConfig := TLSConfig
+	defer func() { TLSConfig = oldTLSConfig }()
+
+	dir := t.TempDir()
+	certPEM, keyPEM := newCertificatePEM(t, "client.pocv.test")
+	certFile, keyFile, bundleFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "bundle.pem")
+	ioutil.WriteFile(certFile, certPEM, 0600)
+	ioutil.WriteFile(keyFile, keyPEM, 0600)
+	ioutil.WriteFile(bundleFile, append(append([]byte{}, certPEM...), keyPEM...), 0600)
+
+	if err := InitTLSConfig(certFile, keyFile, "1.1", "1.2", []string{"TLS_RSA_WITH_AES_128_CBC_SHA"}, "sni.pocv.test"); err != nil {
+		t.Fatal(err)
+	}
+	if len(TLSConfig.Certificates) != 1 || TLSConfig.MinVersion != tls.VersionTLS11 || TLSConfig.MaxVersion != tls.VersionTLS12 ||
+		len(TLSConfig.CipherSuites) != 1 || TLSConfig.ServerName != "sni.pocv.test" || !TLSConfig.InsecureSkipVerify {
+		t.Errorf("unexpected tls config %+v", TLSConfig)
+	}
+
+	// 未指定私钥时从证书文件中读取
+	if err := InitTLSConfig(bundleFile, "", "", "", nil, ""); err != nil || len(TLSConfig.Certificates) != 1 {
+		t.Errorf("InitTLSConfig with bundle file error: %v", err)
+	}
+
+	errorTests := []struct {
+		name                   string
+		certFile, keyFile      string
+		minVersion, maxVersion string
+		ciphers                []string
+	}{
+		{"missing cert", filepath.Join(dir, "missing.pem"), "", "", "", nil},
+		{"missing key", certFile, "", "", "", nil},
+		{"invalid min version", "", "", "1.4", "", nil},
+		{"invalid max version", "", "", "", "2", nil},
+		{"min greater than max", "", "", "1.3", "1.2", nil},
+		{"invalid cipher", "", "", "", "", []string{"TLS_NOT_EXIST"}},
+	}
+	for _, tt := range errorTests {
+		TLSConfig = oldTLSConfig
+		if err := InitTLSConfig(tt.certFile, tt.keyFile, tt.minVersion, tt.maxVersion, tt.ciphers, ""); err == nil {
+			t.Errorf("%s: InitTLSConfig should return error", tt.name)
+		}
+		if TLSConfig != oldTLSConfig {
+			t.Errorf("%s: TLSConfig should not be replaced on error", tt.name)
+		}
+	}
+}
+
+func TestNewTLSClient(t *testing.T) {
+	oldTLSConfig := TLSConfig
+	defer func() { TLSConfig = oldTLSConfig }()
+
+	certPEM, keyPEM := newCertificatePEM(t, "server.pocv.test")
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverNames := make(chan string, 4)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverNames <- hello.ServerName
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				conn.(*tls.Conn).Handshake()
+				conn.Read(make([]byte, 1))
+			}(conn)
+		}
+	}()
+
+	handshake := func(serverName string) *tls.Conn {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		tlsConn, err := NewTLSClient(conn, serverName, "http/1.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tlsConn
+	}
+
+	// 未指定SNI时使用目标的域名
+	if err := InitTLSConfig("", "", "", "1.2", nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	conn := handshake("target.pocv.test")
+	state := conn.ConnectionState()
+	conn.Close()
+	if got := <-serverNames; got != "target.pocv.test" {
+		t.Errorf("server name = %s, want target.pocv.test", got)
+	}
+	info := ParseTLSState(&state)
+	if info.Version != "TLS1.2" || info.NegotiatedProtocol != "http/1.1" || info.Certificate.CommonName != "server.pocv.test" || !info.Certificate.SelfSigned || len(info.Ja3S) != 32 {
+		t.Errorf("unexpected tls info %+v", info)
+	}
+	if TLSConfig.ServerName != "" {
+		t.Error("NewTLSClient should not modify TLSConfig")
+	}
+
+	// 指定SNI时覆盖目标的域名
+	if err := InitTLSConfig("", "", "", "", nil, "sni.pocv.test"); err != nil {
+		t.Fatal(err)
+	}
+	handshake("target.pocv.test").Close()
+	if got := <-serverNames; got != "sni.pocv.test" {
+		t.Errorf("server name = %s, want sni.pocv.test", got)
+	}
+}
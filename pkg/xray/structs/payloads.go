@@ -0,0 +1,64 @@
+package structs
+
+import "gopkg.in/yaml.v2"
+
+const (
+	PayloadModeCartesian = "cartesian"
+	PayloadModePitchfork = "pitchfork"
+)
+
+// 按mode遍历payload变量的组合，fn返回false时停止遍历
+func (p *Payloads) Range(fn func(set yaml.MapSlice) bool) {
+	if len(p.Variables) == 0 {
+		return
+	}
+
+	switch p.Mode {
+	case PayloadModePitchfork:
+		// 以最短的取值列表为准
+		length := len(p.Variables[0].Values)
+		for _, variable := range p.Variables[1:] {
+			if len(variable.Values) < length {
+				length = len(variable.Values)
+			}
+		}
+		for i := 0; i < length; i++ {
+			set := make(yaml.MapSlice, len(p.Variables))
+			for j, variable := range p.Variables {
+				set[j] = yaml.MapItem{Key: variable.Name, Value: variable.Values[i]}
+			}
+			if !fn(set) {
+				return
+			}
+		}
+	case PayloadModeCartesian:
+		for _, variable := range p.Variables {
+			if len(variable.Values) == 0 {
+				return
+			}
+		}
+		// 第一个变量变化最慢
+		indexes := make([]int, len(p.Variables))
+		for {
+			set := make(yaml.MapSlice, len(p.Variables))
+			for j, variable := range p.Variables {
+				set[j] = yaml.MapItem{Key: variable.Name, Value: variable.Values[indexes[j]]}
+			}
+			if !fn(set) {
+				return
+			}
+
+			j := len(indexes) - 1
+			for ; j >= 0; j-- {
+				indexes[j]++
+				if indexes[j] < len(p.Variables[j].Values) {
+					break
+				}
+				indexes[j] = 0
+			}
+			if j < 0 {
+				return
+			}
+		}
+	}
+}
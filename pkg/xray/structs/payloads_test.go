@@ -0,0 +1,60 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func rangePayloads(p *Payloads, limit int) []string {
+	var sets []string
+	p.Range(func(set yaml.MapSlice) bool {
+		s := ""
+		for _, item := range set {
+			s += fmt.Sprintf("%v=%v;", item.Key, item.Value)
+		}
+		sets = append(sets, s)
+		return limit <= 0 || len(sets) < limit
+	})
+	return sets
+}
+
+func TestPayloadsRange(t *testing.T) {
+	variables := []PayloadVariable{
+		{Name: "user", Values: []string{"admin", "root"}},
+		{Name: "pass", Values: []string{"a", "b", "c"}},
+	}
+
+	tests := []struct {
+		name      string
+		mode      string
+		variables []PayloadVariable
+		limit     int
+		want      []string
+	}{
+		{"cartesian", PayloadModeCartesian, variables, 0, []string{
+			"user=admin;pass=a;", "user=admin;pass=b;", "user=admin;pass=c;",
+			"user=root;pass=a;", "user=root;pass=b;", "user=root;pass=c;",
+		}},
+		{"cartesian stop", PayloadModeCartesian, variables, 2, []string{
+			"user=admin;pass=a;", "user=admin;pass=b;",
+		}},
+		{"cartesian empty", PayloadModeCartesian, []PayloadVariable{variables[0], {Name: "pass"}}, 0, nil},
+		{"pitchfork", PayloadModePitchfork, variables, 0, []string{
+			"user=admin;pass=a;", "user=root;pass=b;",
+		}},
+		{"pitchfork stop", PayloadModePitchfork, variables, 1, []string{
+			"user=admin;pass=a;",
+		}},
+		{"no variables", PayloadModeCartesian, nil, 0, nil},
+	}
+
+	for _, tt := range tests {
+		p := &Payloads{Mode: tt.mode, Variables: tt.variables}
+		if got := rangePayloads(p, tt.limit); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
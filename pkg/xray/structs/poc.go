@@ -38,6 +38,28 @@ type RuleRequest struct {
 	Content         string            `yaml:"content"`
 	ReadTimeout     string            `yaml:"read_timeout"`
 	ConnectionID    string            `yaml:"connection_id"`
+	// 原始请求，不经过net/http发送
+	Raw string `yaml:"raw"`
+	// 不经过net/http规范化请求，与raw同时使用时原样发送raw
+	Unsafe bool `yaml:"unsafe"`
+	// 强制使用http2，http目标使用h2c
+	Http2 bool `yaml:"http2"`
+	// tcp连接建立后先进行tls握手，transport为tls时默认开启
+	TLS bool `yaml:"tls"`
+	// content编码，支持hex/base64
+	ContentEncoding string `yaml:"content_encoding"`
+	// 读取到指定字节数后返回
+	ReadSize int `yaml:"read_size"`
+	// 读取到的数据匹配该正则后返回
+	ReadUntil string `yaml:"read_until"`
+	// udp期望收到的报文数，默认为1
+	Datagrams int `yaml:"datagrams"`
+	// udp未收到响应时的重传次数，默认为2，read_timeout平均分配给每次发送
+	Retries *int `yaml:"retries"`
+	// 覆盖目标的scheme、host和port，用于请求同一主机的其他端口
+	Scheme string `yaml:"scheme"`
+	Host   string `yaml:"host"`
+	Port   string `yaml:"port"`
 }
 
 type Infos struct {
@@ -75,8 +97,17 @@ type SetMapSlice = yaml.MapSlice
 type PayloadsMapSlice = yaml.MapSlice
 
 type Payloads struct {
-	Continue bool             `yaml:"continue,omitempty"`
+	Continue bool `yaml:"continue,omitempty"`
+	// payload组合模式，默认依次执行每个payload集合，cartesian为笛卡尔积，pitchfork为按位置一一对应
+	Mode     string           `yaml:"mode,omitempty"`
 	Payloads PayloadsMapSlice `yaml:"payloads"`
+	// cartesian/pitchfork模式下的变量取值，加载poc时生成
+	Variables []PayloadVariable `yaml:"-"`
+}
+
+type PayloadVariable struct {
+	Name   string
+	Values []string
 }
 
 type Poc struct {
@@ -89,6 +120,15 @@ type Poc struct {
 	Detail     Detail       `yaml:"detail"`
 }
 
+// 复制detail，渲染时修改副本，避免多个目标并发执行同一poc时互相影响
+func (d *Detail) Clone() Detail {
+	detail := *d
+	detail.Links = append([]string(nil), d.Links...)
+	detail.FingerPrint.Infos = append([]Infos(nil), d.FingerPrint.Infos...)
+
+	return detail
+}
+
 func (r *Rule) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 	var tmp ruleAlias
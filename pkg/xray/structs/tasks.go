@@ -1,5 +1,8 @@
 package structs
 
+// requests.pb.go 使用 protoc v3.18.0 和 protoc-gen-go v1.26.0 生成
+//go:generate protoc --go_out=. requests.proto
+
 type Task struct {
 	Poc    Poc
 	Target string
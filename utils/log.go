@@ -42,7 +42,7 @@ func InfoF(format string, args ...interface{}) {
 }
 
 func Info(args ...interface{}) {
-	logger.Infoln(args)
+	logger.Infoln(args...)
 }
 
 // Error
@@ -51,7 +51,7 @@ func ErrorF(format string, args ...interface{}) {
 }
 
 func Error(args ...interface{}) {
-	logger.Errorln(args)
+	logger.Errorln(args...)
 }
 
 // PrintError
@@ -82,7 +82,7 @@ func WarningF(format string, args ...interface{}) {
 }
 
 func Warning(args ...interface{}) {
-	logger.Warningln(args)
+	logger.Warningln(args...)
 }
 
 // Debug
@@ -91,5 +91,5 @@ func DebugF(format string, args ...interface{}) {
 }
 
 func Debug(args ...interface{}) {
-	logger.Debugln(args)
+	logger.Debugln(args...)
 }